@@ -0,0 +1,52 @@
+package gofixedwidth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LRC returns the longitudinal redundancy check of line (all the bytes XORed together)
+func LRC(line string) byte {
+	var result byte
+	for i := 0; i < len(line); i++ {
+		result ^= line[i]
+	}
+	return result
+}
+
+// LRCHex can be used as ChecksumFunc, it returns the LRC of the record as two hexadecimal digits
+func LRCHex(b []byte) string {
+	return fmt.Sprintf("%02X", LRC(string(b)))
+}
+
+// checksumLine - fill the checksum field at rng of the final line with the checksum of the rest of the line
+func (w *Writer) checksumLine(line []byte, rng [2]int, align int) ([]byte, error) {
+	rest := make([]byte, 0, len(line)-(rng[1]-rng[0]))
+	rest = append(rest, line[:rng[0]]...)
+	rest = append(rest, line[rng[1]:]...)
+	fld, err := w.appendAligned(nil, w.ChecksumColumn, w.ChecksumFunc(rest), align)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 0, len(rest)+len(fld))
+	result = append(result, line[:rng[0]]...)
+	result = append(result, fld...)
+	return append(result, line[rng[1]:]...), nil
+}
+
+// verifyChecksum - check that the checksum field of a record matches the checksum of the rest of the record
+func (r *Reader) verifyChecksum(tmp string) error {
+	if r.ChecksumFunc == nil || r.ChecksumColumn < 0 || r.ChecksumColumn >= len(r.offsets) {
+		return nil
+	}
+	rng := r.offsets[r.ChecksumColumn]
+	if rng[1] > len(tmp) {
+		return nil
+	}
+	want := strings.TrimSpace(r.ChecksumFunc([]byte(tmp[:rng[0]] + tmp[rng[1]:])))
+	got := strings.TrimSpace(tmp[rng[0]:rng[1]])
+	if got != want {
+		return &ParseError{Line: r.line, Column: r.ChecksumColumn, Err: fmt.Errorf("%w: record has %q but calculated %q", ErrChecksum, got, want)}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLRC(t *testing.T) {
+	if got := LRC("\x01\x02\x04"); got != 0x07 {
+		t.Errorf("LRC: got %#x, want 0x07", got)
+	}
+	if got := LRCHex([]byte("AB")); got != "03" {
+		t.Errorf("LRCHex: got %q, want %q", got, "03")
+	}
+}
+
+func TestChecksumAligned(t *testing.T) {
+	var sb strings.Builder
+	w := newTestWriter(&sb, 3, 4)
+	w.ChecksumFunc = LRCHex
+	w.ChecksumColumn = 1
+	if err := w.WriteAligned([]string{"AB", ""}, []int{ALIGNRIGHT, ALIGNRIGHT}); err != nil {
+		t.Fatalf("WriteAligned: %v", err)
+	}
+	w.Flush()
+	// The checksum is of " AB" and right aligned as asked instead of left aligned by FieldAlign
+	want := " AB  " + LRCHex([]byte(" AB")) + "\n"
+	if sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+	r := newTestReader(sb.String(), 3, 4)
+	r.ChecksumFunc = LRCHex
+	r.ChecksumColumn = 1
+	if _, err := readAll(t, r); err != nil {
+		t.Errorf("reading it back: %v", err)
+	}
+}
+
+func TestChecksumPostFormat(t *testing.T) {
+	var sb strings.Builder
+	w := newTestWriter(&sb, 3, 2)
+	w.ChecksumFunc = LRCHex
+	w.ChecksumColumn = 1
+	w.PostFormat = strings.ToUpper
+	if err := w.Write([]string{"abc", ""}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Flush()
+	// The checksum is of the line that is written
+	if want := "ABC" + LRCHex([]byte("ABC")) + "\n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+
+	w = newTestWriter(&sb, 3, 2)
+	w.ChecksumFunc = LRCHex
+	w.ChecksumColumn = 1
+	w.PostFormat = func(line string) string { return line + "!" }
+	if err := w.Write([]string{"abc", ""}); !errors.Is(err, ErrIncorrectLineWidth) {
+		t.Errorf("got error %v, want ErrIncorrectLineWidth when PostFormat changes the width", err)
+	}
+}
+
+func TestChecksumWriteField(t *testing.T) {
+	var sb strings.Builder
+	w := newTestWriter(&sb, 4, 2)
+	w.ChecksumFunc = LRCHex
+	w.ChecksumColumn = 1
+	if err := w.WriteField(0, "abcd"); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("WriteField: got error %v, want ErrInvalidLayout", err)
+	}
+	if err := w.EndRecord(); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("EndRecord: got error %v, want ErrInvalidLayout", err)
+	}
+	w.Flush()
+	if sb.String() != "" {
+		t.Errorf("got %q written, want nothing", sb.String())
+	}
+}
@@ -0,0 +1,125 @@
+package gofixedwidth
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Transform reads every record from r, passes it through mapFn and writes the result with w.
+// The records are handled one at a time so the input is never completely in memory.
+// mapFn can reorder, drop or derive fields, if it is nil the records are written as read.
+// It stops at the first error and the output is flushed at the end.
+func Transform(r *Reader, w *Writer, mapFn func([]string) ([]string, error)) error {
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Flush()
+			return err
+		}
+		if mapFn != nil {
+			record, err = mapFn(record)
+			if err != nil {
+				w.Flush()
+				return err
+			}
+		}
+		if err = w.Write(record); err != nil {
+			w.Flush()
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// FromCSV reads every row from r and writes it with w, so the fields are padded and aligned to the layout of w.
+// The rows are handled one at a time and the output is flushed at the end. A row that can't be written
+// (for example because it doesn't have a field for every entry of FieldLengths) stops the conversion
+// with a RecordError that has the index of the row. Quoted fields with the Comma of r or line delimeters are
+// written as they are, set StripControls or SanitizeFunc (for example CollapseSpaces) on w to clean them up.
+func FromCSV(r *csv.Reader, w *Writer) error {
+	for row := 0; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Flush()
+			return err
+		}
+		if err = w.Write(record); err != nil {
+			w.Flush()
+			return &RecordError{Index: row, Err: err}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// ToCSV reads every record from r and writes it as a row with w, the csv.Writer quotes the fields that contain its
+// Comma, quotes, line delimeters or leading spaces so the values are kept as they are. The records are handled one
+// at a time and w is flushed at the end. Set CollapseSpaces on r to normalize the white space inside the fields.
+func ToCSV(r *Reader, w *csv.Writer) error {
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Flush()
+			return err
+		}
+		if err = w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// CollapseSpaces replaces every run of white space in s (including tabs and line delimeters) by a single space
+// and removes the white space at the start and end. It can be used as the SanitizeFunc of a Writer to
+// normalize values from FromCSV.
+func CollapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// RoundTrip reads data with a Reader configured by cfg (after NewReader and before Init) and writes the records
+// back with a Writer that has the same layout: SkipStart, SkipEnd, FieldLengths, FieldAlign and HasEOL.
+// It returns the output so a test can check that a layout reproduces its input byte for byte.
+// Lines that are skipped (SkipLines, comments and empty lines) are not written and the fields must be
+// contiguous (FieldRanges with gaps or overlaps can't be written), otherwise ErrInvalidLayout is returned.
+func RoundTrip(data string, cfg func(*Reader)) (string, error) {
+	r := NewReader(strings.NewReader(data))
+	if cfg != nil {
+		cfg(r)
+	}
+	if err := r.Init(); err != nil {
+		return "", err
+	}
+	offset := r.SkipStart
+	for _, fld := range r.Layout() {
+		if fld.Offset != offset {
+			return "", fmt.Errorf("%w: field at offset %d doesn't follow the previous field", ErrInvalidLayout, fld.Offset)
+		}
+		offset += fld.Length
+	}
+	var sb strings.Builder
+	w := NewWriterEOL(&sb, r.HasEOL)
+	w.SkipStart = r.SkipStart
+	w.SkipEnd = r.width - offset
+	w.FieldLengths = r.FieldLengths
+	w.FieldAlign = r.FieldAlign
+	if err := w.Init(); err != nil {
+		return "", err
+	}
+	if err := Transform(r, w, nil); err != nil {
+		return sb.String(), err
+	}
+	return sb.String(), nil
+}
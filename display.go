@@ -0,0 +1,118 @@
+package gofixedwidth
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultTabWidth is the distance between tab stops used for display widths if TabWidth isn't defined
+const defaultTabWidth = 8
+
+// wideRanges are the East Asian wide and fullwidth ranges that take up two columns on a terminal
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe30, 0xfe4f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f300, 0x1f64f},
+	{0x1f900, 0x1f9ff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
+
+// runeColumns - the number of columns a rune takes up on a terminal
+func (w *Writer) runeColumns(c rune) int {
+	if unicode.Is(unicode.Mn, c) || unicode.Is(unicode.Me, c) || unicode.IsControl(c) {
+		return 0
+	}
+	if w.EastAsianWidths {
+		for _, rng := range wideRanges {
+			if c >= rng[0] && c <= rng[1] {
+				return 2
+			}
+		}
+	}
+	return 1
+}
+
+// tabWidth - the distance between tab stops
+func (w *Writer) tabWidth() int {
+	if w.TabWidth > 0 {
+		return w.TabWidth
+	}
+	return defaultTabWidth
+}
+
+// displayWidth - the number of columns the value takes up on a terminal,
+// tab stops are calculated from the start of the value
+func (w *Writer) displayWidth(fld string) int {
+	col := 0
+	for _, c := range fld {
+		if c == '\t' {
+			col += w.tabWidth() - col%w.tabWidth()
+			continue
+		}
+		col += w.runeColumns(c)
+	}
+	return col
+}
+
+// truncateDisplay - cut the value to at most n columns and return it with its display width
+func (w *Writer) truncateDisplay(fld string, n int) (string, int) {
+	col := 0
+	for i, c := range fld {
+		next := col + w.runeColumns(c)
+		if c == '\t' {
+			next = col + w.tabWidth() - col%w.tabWidth()
+		}
+		if next > n {
+			return fld[:i], col
+		}
+		col = next
+	}
+	return fld, col
+}
+
+// valueWidth - the width of a value in bytes or in display columns (if DisplayWidths is set)
+func (w *Writer) valueWidth(fld string) int {
+	if w.DisplayWidths {
+		return w.displayWidth(fld)
+	}
+	return len(fld)
+}
+
+// truncateValue - cut the value to at most n bytes or display columns (if DisplayWidths is set)
+// and return it with its new width
+func (w *Writer) truncateValue(fld string, n int) (string, int) {
+	if w.DisplayWidths {
+		return w.truncateDisplay(fld, n)
+	}
+	if len(fld) > n {
+		return fld[:n], n
+	}
+	return fld, len(fld)
+}
+
+// sanitize - clean a value with SanitizeFunc (if defined) or remove its control characters (if StripControls is set)
+// before it is measured
+func (w *Writer) sanitize(fld string) string {
+	if w.SanitizeFunc != nil {
+		return w.SanitizeFunc(fld)
+	}
+	if w.StripControls {
+		return strings.Map(func(c rune) rune {
+			if unicode.IsControl(c) {
+				return -1
+			}
+			return c
+		}, fld)
+	}
+	return fld
+}
@@ -21,6 +21,10 @@ package gofixedwidth
 
     For each line a slice of strings are returned when read
 
+    Comment lines are not counted by SkipLines unless SkipCountsComments is
+    set, so SkipLines skips that many lines that are not comments. Earlier
+    versions counted every physical line.
+
 CONSTANTS
 
 const (
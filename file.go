@@ -0,0 +1,68 @@
+package gofixedwidth
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// OpenFile opens the file at path and returns a Reader for it. If the file name ends with .gz
+// or the file starts with the gzip magic bytes it is decompressed transparently.
+// cfg (if not nil) is called to define the layout of the Reader before Init is called.
+// Close must be called on the Reader to close the decompressor and the file.
+func OpenFile(path string, cfg func(*Reader)) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	closers := multiCloser{f}
+	var src io.Reader = bufio.NewReader(f)
+	magic, _ := src.(*bufio.Reader).Peek(len(gzipMagic))
+	if strings.HasSuffix(strings.ToLower(path), ".gz") || bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		closers = multiCloser{gz, f}
+		src = gz
+	}
+	r := NewReader(src)
+	r.closer = closers
+	if cfg != nil {
+		cfg(r)
+	}
+	if err = r.Init(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close closes anything opened for the Reader by OpenFile, for other readers it does nothing
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer.Close()
+	r.closer = nil
+	return err
+}
+
+// multiCloser - closes every closer in order and returns the first error
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var result error
+	for _, c := range m {
+		if err := c.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
@@ -17,10 +17,14 @@ package gofixedwidth
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -30,9 +34,14 @@ const (
 	EOLCRLF
 )
 
+// utf8BOM is the byte order mark that can start UTF-8 files
+const utf8BOM = "\xef\xbb\xbf"
+
 const (
 	ALIGNLEFT = iota
 	ALIGNRIGHT
+	ALIGNRIGHTBLANK // Right aligned but an empty value is written as spaces only
+	ALIGNDECIMAL    // Aligned on the decimal point with FieldDecimals positions after it
 )
 
 // Used to generate any errors experienced
@@ -46,12 +55,48 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
 }
 
+// Unwrap returns the underlying error so that errors.Is and errors.As can be used on a ParseError
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// RecordError is the error of a record that couldn't be written by WriteAll (when ContinueOnError is set) or FromCSV
+type RecordError struct {
+	Index int // Index of the record in the slice given to WriteAll or of the row of the CSV input
+	Err   error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %s", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error so that errors.Is and errors.As can be used on a RecordError
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
 var (
 	ErrFieldCount         = errors.New("wrong number of fields in line")
 	ErrNoFields           = errors.New("no fields defined to read")
 	ErrFieldLengthError   = errors.New("fields width incorrect")
 	ErrIncorrectLineWidth = errors.New("incorrect line width")
 	ErrNotEnoughLines     = errors.New("not enough lines")
+	ErrTooManyLines       = errors.New("too many lines")
+	ErrNoFieldNames       = errors.New("field names don't match the fields")
+	ErrInvalidCharacter   = errors.New("invalid character in line")
+	ErrNegativeSkip       = errors.New("negative SkipStart or SkipEnd")
+	ErrFieldOrder         = errors.New("field written out of order")
+	ErrMissingCRLF        = errors.New("CRLF not found at end of line")
+	ErrUnknownEOL         = errors.New("unknown HasEOL value")
+	ErrMissingEOL         = errors.New("line delimeter not found at end of record")
+	ErrUnknownRecordType  = errors.New("unknown record type")
+	ErrFieldTooLong       = errors.New("field value too long")
+	ErrWriterClosed       = errors.New("writer is closed")
+	ErrLineTooLong        = errors.New("line too long")
+	ErrChecksum           = errors.New("checksum doesn't match")
+	ErrRuneBoundary       = errors.New("field boundary inside a multibyte character")
+	ErrMissingField       = errors.New("field missing from map")
+	ErrInvalidPadding     = errors.New("invalid padding in field")
 )
 
 // Reader is used to control the reading from the input stream
@@ -63,168 +108,810 @@ var (
 //   HasEOL - indicates if lines have a CRLF or LF, or CR, when writing a CR + LF will be appended
 //   FieldLengths - is a slice with the lengths of the fields
 //	 FieldAlign - this slice contains the alignment of the field (not really of use with reading)
+//   FieldNames - optional names of the fields (as loaded from a Layout)
+//   NDJSON - if set ReadJSON writes one JSON object per line instead of a JSON array
+//   JSONTypes - maps a field name to "number" or "bool" so ReadJSON doesn't quote the value
+//   ExpectedWidth - if defined Init checks that the skips and field lengths add up to this width
+//   RecordLines - the number of physical lines that are joined into one record (only used if HasEOL is defined)
+//   FieldRanges - absolute [start,end) byte ranges of the fields, gaps and overlaps are allowed.
+//     Overlapping ranges (like a COBOL REDEFINES) each return their own view of the same bytes, for example a
+//     date and its day, month and year. If defined it takes precedence over FieldLengths (which Init then
+//     derives from it) and SkipStart is not used
+//   AllowTrailingBytes - if set any bytes on a line after the record width are discarded instead of being an error.
+//     With EOLNONE exactly the record width is read so there are never trailing bytes
+//   ASCIIOnly - if set any byte outside of printable ASCII (0x20-0x7E) in a record is an error,
+//     the Column of the returned ParseError is the offset of the byte in the line
+//   ClampSkips - if set a negative SkipStart or SkipEnd is set to 0 by Init instead of returning ErrNegativeSkip
+//   NullField - if defined a field that is empty (after trimming) is returned as this value (for example "\\N")
+//   TabWidth - if defined tabs on a line are expanded to spaces up to the next multiple of TabWidth before
+//     the width is checked (not used with EOLNONE where exactly the record width is read)
+//   MaxFieldLen - optional maximum length of each field's value (after trimming), 0 means no check
+//   CheckRuneBoundaries - if set every field must start and end on a UTF-8 character boundary
+//   DisableComment - if set Comment is ignored and lines starting with it are read as records,
+//     it can be changed between reads
+//   FixedWidthEOL - if set with a HasEOL other than EOLNONE exactly the record width is read and then the
+//     line delimeter is checked and skipped, so fields may contain CR or LF bytes
+//   StripBOM - if set a UTF-8 byte order mark at the start of the input is skipped
+//   LastFieldRaw - if set the last field is the rest of the line, it is not trimmed and its length isn't checked
+//     (only used if records end at a line delimeter)
+//   WidthFor - if defined it is called with the first PrefixLen bytes of every record and returns the width
+//     of that record (at most the full width), fields past the end of a shorter record are returned empty (or as NullField).
+//     Returning 0 means the prefix is not recognized and ErrUnknownRecordType is returned
+//   PrefixLen - the number of bytes passed to WidthFor
+//   FieldTypes - the type each field is converted to by ReadTyped (TYPESTRING if not defined)
+//   TimeLayout - the layout used by ReadTyped to parse TYPETIME fields (time.RFC3339 if not defined)
+//   FieldDateLayouts - optional time.Parse layout per field (for example "20060102"), a field with a layout is read as a date by ReadTyped
+//   RecordSep - if defined records end at this byte (for example a form feed) instead of the line delimeter of HasEOL.
+//     It takes precedence over HasEOL and FixedWidthEOL, HasEOL then only defines which of CR and LF are not allowed
+//     inside a record (none with EOLNONE)
+//   FieldFiller - if defined this byte (for example 0xFF) is stripped from the start and end of every field.
+//     It is stripped independently of TrimFields, if both are set the filler, spaces and tabs are stripped together
+//   FieldTransform - optional function per field (nil for none) applied to the value after trimming and before
+//     MaxFieldLen and NullField, for example strings.ToUpper to normalize codes
+//   SkipCountsComments - if set comment lines count towards SkipLines, by default (false) comment lines
+//     between the skipped lines are skipped as well without being counted (earlier versions counted every line)
+//   FieldEncoding - optional Decoder per field (nil to use the bytes as is) that decodes the field before it is
+//     trimmed and checked, for example EBCDIC for the EBCDIC columns of a mainframe record or PackedDecoder for
+//     packed decimal (COMP-3) fields (use FixedWidthEOL then as packed bytes may look like CR or LF)
+//   StrictTrim - if set TrimFields and FieldFiller only remove the padding on the side given by FieldAlign
+//     (the end of left aligned fields and the start of right aligned fields) so leading or trailing data is kept.
+//     Fields without an alignment (or aligned on the decimal point) are still trimmed on both sides
+//   ConsistentWidth - if set every record must have the same length (including any trailing bytes or a raw last field)
+//     as the first record, otherwise a ParseError with the line of the record is returned
+//   ChecksumFunc - if defined it is called with the raw record without the ChecksumColumn field and the result
+//     must match the value of that field (ignoring spaces), otherwise ErrChecksum is returned (for example LRCHex)
+//   ChecksumColumn - the index of the checksum field checked with ChecksumFunc
+//   CommentOffset - the byte offset in the line where Comment is looked for (0, the start of the line, by default),
+//     for layouts where a flag column marks the lines that are not data
+//   CommentAfterSkip - if set CommentOffset is counted from SkipStart instead of the start of the line, so a comment
+//     marker at the first data byte is found when the skip region holds something else
+//   StripLeadingZeros - optional flag per field to remove the leading zeros of a number after it is checked
+//     ("000042" is read as "42", "-00042" as "-42" and "0000" as "0")
+//   HasLengthField - if set the record has a variable segment after its fixed part with the length given by LengthField
+//   LengthField - the index of the field that holds the length of the variable segment that follows the fixed part of
+//     the record (after SkipEnd) when HasLengthField is set, the segment is returned untrimmed as an extra last field.
+//     It can't be combined with WidthFor, LastFieldRaw or FixedWidthEOL
+//   OnRecord - optional hook called by ReadAll with the line number and fields of every record read
+//   OnError - optional hook called by ReadAll with the error that stops it (not for io.EOF or a cancelled context)
+//   MaxLineLen - if defined a ParseError (with ErrLineTooLong) is returned when no line delimeter is found within
+//     this many bytes, so a corrupt input can't use up all memory. 0 means no limit
+//   WholeLine - if set and FieldLengths (and FieldRanges) are empty every line without SkipStart and SkipEnd is
+//     returned as a single field. Lines can then have any length of at least SkipStart+SkipEnd and ExpectedWidth
+//     is not checked. It is only used if records end at a line delimeter
+//   TrailerMarker - if defined a line that starts with it is the trailer record, it ends the records
+//   TrailerLast - if set the last line (that isn't a comment or empty) is the trailer record. The next line is read
+//     ahead, so it can't be used with RecordLines and WriteTo can't be used once a record has been read
+//   TrailerLengths - the lengths of the fields of the trailer from the start of the line, the trailer can have another
+//     width than the records. The trailer is returned by Trailer as one field if it isn't defined
+//   CollapseSpaces - if set every run of white space in a field (including tabs and line delimeters) is replaced by a
+//     single space and the field is trimmed, after FieldTransform (see CollapseSpaces)
+//   VerifyPadding - if set the padding of every field (the end of left aligned and the start of right aligned fields)
+//     may only be FieldFiller (or spaces if it isn't defined). A space, tab or control character next to the padding
+//     returns a ParseError (with ErrInvalidPadding) instead of being trimmed. Raw and encoded fields are not checked
+//   SplitFunc - optional bufio.SplitFunc (like the Split of a bufio.Scanner) that decides where each record ends.
+//     It is called with the unread input and atEOF and returns how many bytes to advance, the record (nil if more
+//     input is needed) and an error (bufio.ErrFinalToken ends the input after the record). It takes precedence over
+//     RecordSep, HasEOL and FixedWidthEOL, HasEOL then only defines which of CR and LF are not allowed inside a record
+//   StrictWidth - if set (the default of NewReader) a record must be exactly the record width. If it is not set a
+//     shorter line is padded with FieldFiller (or spaces) and a longer one is cut to the width, so ragged lines are
+//     read but a missing or extra byte in the middle of a line shifts the fields after it without an error.
+//     Records read by width, WidthFor and LengthField are always checked
+//   StreamBuffer - the number of records Stream reads ahead of the receiver (0 hands every record over directly)
 type Reader struct {
-	Comment         rune
-	SkipLines       int
-	SkipStart       int
-	SkipEnd         int
-	FieldLengths    []int
-	FieldAlign      []int
-	TrimFields      bool
-	HasEOL          int
-	width           int
-	line            int
-	column          int
-	initialskipdone bool
-	r               *bufio.Reader
-}
-
-// readLine - read the next line from input based on the type of line delimeter (or none)
+	Comment             rune
+	SkipLines           int
+	SkipStart           int
+	SkipEnd             int
+	FieldLengths        []int
+	FieldAlign          []int
+	FieldNames          []string
+	TrimFields          bool
+	NDJSON              bool
+	JSONTypes           map[string]string
+	ExpectedWidth       int
+	RecordLines         int
+	FieldRanges         [][2]int
+	AllowTrailingBytes  bool
+	ASCIIOnly           bool
+	ClampSkips          bool
+	NullField           string
+	TabWidth            int
+	MaxFieldLen         []int
+	CheckRuneBoundaries bool
+	DisableComment      bool
+	FixedWidthEOL       bool
+	StripBOM            bool
+	LastFieldRaw        bool
+	WidthFor            func(prefix string) int
+	PrefixLen           int
+	FieldTypes          []FieldType
+	TimeLayout          string
+	FieldDateLayouts    []string
+	RecordSep           byte
+	FieldFiller         byte
+	FieldTransform      []func(string) string
+	SkipCountsComments  bool
+	FieldEncoding       []Decoder
+	StrictTrim          bool
+	ConsistentWidth     bool
+	ChecksumFunc        func([]byte) string
+	ChecksumColumn      int
+	CommentOffset       int
+	CommentAfterSkip    bool
+	StripLeadingZeros   []bool
+	HasLengthField      bool
+	LengthField         int
+	OnRecord            func(line int, fields []string)
+	OnError             func(*ParseError)
+	MaxLineLen          int
+	WholeLine           bool
+	TrailerMarker       string
+	TrailerLast         bool
+	TrailerLengths      []int
+	CollapseSpaces      bool
+	VerifyPadding       bool
+	SplitFunc           bufio.SplitFunc
+	StrictWidth         bool
+	StreamBuffer        int
+	HasEOL              int
+	width               int
+	offsets             [][2]int
+	rawwidth            int
+	line                int
+	column              int
+	initialskipdone     bool
+	closer              io.Closer
+	lastline            string
+	lasteol             int
+	firstwidth          int
+	wholeline           bool
+	bomchecked          bool
+	ra                  io.ReaderAt
+	recordwidth         int
+	record              []string
+	scanerr             error
+	linebuf             []byte
+	trailer             []string
+	peek                *peekLine
+	splitbuf            []byte
+	spliteof            bool
+	splitdone           bool
+	fieldbuf            [][]byte
+	cr                  *countReader
+	r                   *bufio.Reader
+}
 
+// readLine - read the next line from input and expand any tabs (if TabWidth is defined)
 func (r *Reader) readLine() (string, error) {
+	tmp, err := r.readRawLine()
+	if err == nil && r.TabWidth > 0 && !r.byWidth() {
+		tmp = expandTabs(tmp, r.TabWidth)
+	}
+	return tmp, err
+}
+
+// byWidth - check if records are read by width instead of up to a line delimeter
+func (r *Reader) byWidth() bool {
+	return r.RecordSep == 0 && r.SplitFunc == nil && (r.HasEOL == EOLNONE || r.FixedWidthEOL)
+}
+
+// expandTabs - replace every tab with spaces up to the next tab stop
+func expandTabs(line string, tabwidth int) string {
+	if strings.IndexByte(line, '\t') < 0 {
+		return line
+	}
+	var sb strings.Builder
+	col := 0
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\t' {
+			n := tabwidth - col%tabwidth
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		sb.WriteByte(line[i])
+		col++
+	}
+	return sb.String()
+}
+
+// readRawLine - read the next line from input based on the type of line delimeter (or none)
+func (r *Reader) readRawLine() (string, error) {
+	r.line++
+	r.lasteol = EOLNONE
+	if !r.bomchecked {
+		r.bomchecked = true
+		if r.StripBOM {
+			if b, _ := r.r.Peek(len(utf8BOM)); string(b) == utf8BOM {
+				r.r.Discard(len(utf8BOM))
+			}
+		}
+	}
+	if r.SplitFunc != nil {
+		return r.readSplit()
+	}
+	if r.RecordSep != 0 {
+		return r.readUntil(r.RecordSep, EOLNONE)
+	}
+	if r.FixedWidthEOL && r.HasEOL != EOLNONE {
+		return r.readWidthEOL()
+	}
 	switch r.HasEOL {
 	// Read up to the first CR
 	case EOLCR:
-		tmp, err := r.r.ReadString(13)
-		if err != nil {
-			return tmp, err
-		}
-		return tmp[:len(tmp)-1], nil
+		return r.readUntil(13, EOLCR)
 
 		// Read up to the first LF
 	case EOLLF:
-		tmp, err := r.r.ReadString(10)
-		if err != nil {
-			return tmp, err
-		}
-		return tmp[:len(tmp)-1], nil
+		return r.readUntil(10, EOLLF)
 
 		// Read up to the first CR and LF
 	case EOLCRLF:
-		tmp, err := r.r.ReadString(13)
+		tmp, err := r.readString(13)
+		if err == io.EOF && len(tmp) > 0 {
+			return tmp, nil // The last line doesn't have a CRLF
+		}
 		if err != nil {
 			return tmp, err
 		}
 		b, err := r.r.ReadByte()
+		if err == io.EOF {
+			r.lasteol = EOLCR
+			return tmp[:len(tmp)-1], nil // The last line only ends with a CR
+		}
 		if err != nil {
 			return tmp, err
 		}
 		if err == nil && b == 10 {
-			return tmp, nil
+			r.lasteol = EOLCRLF
+			return tmp[:len(tmp)-1], nil
 		}
-		return tmp[:len(tmp)-1], errors.New("CRLF not found at end of line")
+		return tmp[:len(tmp)-1], ErrMissingCRLF
 
 		// Read number of bytes based on width of fields
 	case EOLNONE:
-		tmp2 := make([]byte, r.width)
-		_, err := r.r.Read(tmp2)
-		if err != nil {
-			return "", err
+		if r.HasLengthField {
+			return r.readLengthSegment()
+		}
+		if r.WidthFor != nil {
+			return r.readPrefixWidth()
+		}
+		return r.readWidth()
+	}
+	return "", ErrUnknownEOL
+}
+
+// stripLeadingZeros - remove the zeros in front of a number (after its sign), a zero directly in front
+// of the decimal point or the end of the value is kept
+func stripLeadingZeros(field string) string {
+	start := 0
+	if strings.HasPrefix(field, "-") || strings.HasPrefix(field, "+") {
+		start = 1
+	}
+	end := start
+	for end < len(field)-1 && field[end] == '0' && field[end+1] >= '0' && field[end+1] <= '9' {
+		end++
+	}
+	return field[:start] + field[end:]
+}
+
+// trimPad - remove the filler bytes (if defined) from the start (if leading is set) and the end (if trailing is set)
+// of a field, and spaces and tabs as well if spaces is set
+func trimPad[T string | []byte](field T, filler byte, spaces, leading, trailing bool) T {
+	pad := func(b byte) bool {
+		return (filler != 0 && b == filler) || (spaces && (b == ' ' || b == '\t'))
+	}
+	for leading && len(field) > 0 && pad(field[0]) {
+		field = field[1:]
+	}
+	for trailing && len(field) > 0 && pad(field[len(field)-1]) {
+		field = field[:len(field)-1]
+	}
+	return field
+}
+
+// validPadding - check that the padding of field i is only the pad character (FieldFiller or a space)
+// and that the value next to it doesn't start or end with other white space or control characters
+func (r *Reader) validPadding(i int, field string) bool {
+	bad := func(b byte) bool {
+		return b == ' ' || b == '\t' || b < 0x20 || b == 0x7f
+	}
+	if i < len(r.FieldAlign) && (r.FieldAlign[i] == ALIGNRIGHT || r.FieldAlign[i] == ALIGNRIGHTBLANK) {
+		field = trimPad(field, r.padByte(), false, true, false)
+		return field == "" || !bad(field[0])
+	}
+	field = trimPad(field, r.padByte(), false, false, true)
+	return field == "" || !bad(field[len(field)-1])
+}
+
+// padByte - the byte fields are padded with, FieldFiller or a space if it isn't defined
+func (r *Reader) padByte() byte {
+	if r.FieldFiller != 0 {
+		return r.FieldFiller
+	}
+	return ' '
+}
+
+// trimSides - the sides of field i that are trimmed, with StrictTrim only the side where the
+// alignment of the field puts the padding
+func (r *Reader) trimSides(i int) (leading, trailing bool) {
+	if !r.StrictTrim || i >= len(r.FieldAlign) {
+		return true, true
+	}
+	switch r.FieldAlign[i] {
+	case ALIGNLEFT:
+		return false, true
+	case ALIGNRIGHT, ALIGNRIGHTBLANK:
+		return true, false
+	}
+	return true, true
+}
+
+// eolChars - the chars of the line delimeter defined by eol
+func eolChars(eol int) string {
+	switch eol {
+	case EOLCR:
+		return "\r"
+	case EOLLF:
+		return "\n"
+	case EOLCRLF:
+		return "\r\n"
+	}
+	return ""
+}
+
+// readString - read up to and including delim, if MaxLineLen is defined a ParseError is returned
+// as soon as the line (without delim) is longer than it
+func (r *Reader) readString(delim byte) (string, error) {
+	if r.MaxLineLen <= 0 {
+		return r.r.ReadString(delim)
+	}
+	var buf []byte
+	for {
+		part, err := r.r.ReadSlice(delim)
+		buf = append(buf, part...)
+		n := len(buf)
+		if n > 0 && buf[n-1] == delim && err == nil {
+			n--
+		}
+		if n > r.MaxLineLen {
+			return "", &ParseError{Line: r.line, Column: r.MaxLineLen, Err: fmt.Errorf("%w: more than %d bytes", ErrLineTooLong, r.MaxLineLen)}
+		}
+		if err != bufio.ErrBufferFull {
+			return string(buf), err
+		}
+	}
+}
+
+// readUntil - read up to delim and return the line without it, eol is recorded as the last EOL if delim was found
+// The last line of the input doesn't need to end with delim
+func (r *Reader) readUntil(delim byte, eol int) (string, error) {
+	tmp, err := r.readString(delim)
+	if err == io.EOF && len(tmp) > 0 {
+		return tmp, nil
+	}
+	if err != nil {
+		return tmp, err
+	}
+	r.lasteol = eol
+	return tmp[:len(tmp)-1], nil
+}
+
+// readWidth - read exactly the width of a record, a short record at the end of the input is returned as is
+func (r *Reader) readWidth() (string, error) {
+	tmp := make([]byte, r.width)
+	n, err := io.ReadFull(r.r, tmp)
+	if err == io.ErrUnexpectedEOF {
+		return string(tmp[:n]), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(tmp), nil
+}
+
+// readPrefixWidth - read the first PrefixLen bytes of a record and then the rest of the record
+// based on the width returned by WidthFor
+func (r *Reader) readPrefixWidth() (string, error) {
+	prefix := make([]byte, r.PrefixLen)
+	n, err := io.ReadFull(r.r, prefix)
+	if err == io.ErrUnexpectedEOF {
+		return string(prefix[:n]), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	width, err := r.recordWidth(string(prefix))
+	if err != nil {
+		return "", err
+	}
+	if width < r.PrefixLen {
+		return string(prefix), nil
+	}
+	rest := make([]byte, width-r.PrefixLen)
+	n, err = io.ReadFull(r.r, rest)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return string(prefix) + string(rest[:n]), nil
+}
+
+// readWidthEOL - read exactly the width of a record followed by the line delimeter defined by HasEOL
+// The width decides where the record ends, the line delimeter is only checked and skipped
+func (r *Reader) readWidthEOL() (string, error) {
+	tmp, err := r.readWidth()
+	if err != nil || len(tmp) < r.width {
+		return tmp, err
+	}
+	eol := eolChars(r.HasEOL)
+	if eol == "" {
+		return "", ErrUnknownEOL
+	}
+	for i := 0; i < len(eol); i++ {
+		b, err := r.r.ReadByte()
+		// The last record doesn't need a line delimeter
+		if err == io.EOF && i == 0 {
+			return tmp, nil
+		}
+		if err != nil || b != eol[i] {
+			return tmp, ErrMissingEOL
 		}
-		tmp3 := string(tmp2)
-		return tmp3, nil
 	}
-	return "", errors.New("Nothing to return")
+	r.lasteol = r.HasEOL
+	return tmp, nil
 }
 
 // Init updates width before everyline seeing that input
 // can have different lines and thus the details can differ
+// It can be called again after the layout is changed, FieldAlign is then fitted to the new FieldLengths
 func (r *Reader) Init() error {
-	if r.SkipStart < 0 {
-		r.SkipStart = 0
+	// The variable segment has to follow a fixed part that ends at a known width
+	if r.HasLengthField && (r.WidthFor != nil || r.LastFieldRaw || r.FixedWidthEOL) {
+		return fmt.Errorf("%w: HasLengthField can't be used with WidthFor, LastFieldRaw or FixedWidthEOL", ErrInvalidLayout)
 	}
-	if r.SkipEnd < 0 {
-		r.SkipEnd = 0
+	if r.SkipStart < 0 || r.SkipEnd < 0 {
+		if !r.ClampSkips {
+			return ErrNegativeSkip
+		}
+		// Negative skips are treated as 0 if clamping was asked for
+		if r.SkipStart < 0 {
+			r.SkipStart = 0
+		}
+		if r.SkipEnd < 0 {
+			r.SkipEnd = 0
+		}
 	}
-	r.width = r.SkipStart + r.SkipEnd
-	if len(r.FieldLengths) == 0 {
-		return ErrNoFields
+	// The line read ahead to find the last one would be taken for the first continuation line
+	if r.TrailerLast && r.RecordLines > 1 && !r.byWidth() {
+		return fmt.Errorf("%w: TrailerLast can't be used with RecordLines", ErrInvalidLayout)
 	}
-	for _, val := range r.FieldLengths {
-		if val <= 0 {
-			return ErrFieldLengthError
+	if len(r.FieldRanges) > 0 {
+		if err := r.initRanges(); err != nil {
+			return err
+		}
+	} else {
+		r.width = r.SkipStart + r.SkipEnd
+		r.wholeline = r.WholeLine && len(r.FieldLengths) == 0 && !r.byWidth()
+		if r.wholeline { // The line without the skips is the only field
+			r.offsets = [][2]int{{r.SkipStart, r.SkipStart}}
+			r.rawwidth = r.width
+			return nil
+		}
+		if len(r.FieldLengths) == 0 {
+			return ErrNoFields
+		}
+		r.offsets = make([][2]int, 0, len(r.FieldLengths))
+		curpos := r.SkipStart // Fields follow each other after SkipStart
+		for _, val := range r.FieldLengths {
+			if val <= 0 {
+				return ErrFieldLengthError
+			}
+			r.offsets = append(r.offsets, [2]int{curpos, curpos + val})
+			curpos += val
+		}
+		r.width = RequiredWidth(r.FieldLengths, r.SkipStart, r.SkipEnd)
+	}
+	// Check the layout against the expected width (if defined)
+	if r.ExpectedWidth > 0 && r.width != r.ExpectedWidth {
+		return fmt.Errorf("%w: expected width %d but layout is %d", ErrFieldLengthError, r.ExpectedWidth, r.width)
+	}
+	// Create a default FieldAlign (or fit it to the fields) with the other fields aligned left
+	r.FieldAlign = fitAlign(r.FieldAlign, len(r.FieldLengths))
+	// With a raw last field a line only has to reach the start of the last field and the end of the others
+	last := len(r.offsets) - 1
+	r.rawwidth = r.offsets[last][0]
+	for _, rng := range r.offsets[:last] {
+		if rng[1] > r.rawwidth {
+			r.rawwidth = rng[1]
 		}
-		r.width += val
 	}
-	// Create a default FieldAlign if none found with all fields aligned left
-	if r.FieldAlign == nil {
-		r.FieldAlign = make([]int, len(r.FieldLengths))
-		for i := 0; i < len(r.FieldAlign); i++ {
-			r.FieldAlign[i] = ALIGNLEFT
+	return nil
+}
+
+// initRanges - derive the offsets, FieldLengths and width from FieldRanges
+// The ranges are not checked against each other so they can overlap
+// The width is the furthest end of any range plus SkipEnd, SkipStart is not used
+func (r *Reader) initRanges() error {
+	maxend := 0
+	r.offsets = make([][2]int, 0, len(r.FieldRanges))
+	r.FieldLengths = make([]int, 0, len(r.FieldRanges))
+	for _, rng := range r.FieldRanges {
+		if rng[0] < 0 || rng[1] <= rng[0] {
+			return ErrFieldLengthError
+		}
+		if rng[1] > maxend {
+			maxend = rng[1]
 		}
+		r.offsets = append(r.offsets, rng)
+		r.FieldLengths = append(r.FieldLengths, rng[1]-rng[0])
 	}
+	r.width = maxend + r.SkipEnd
 	return nil
 }
 
 // NewReader returns a struct with the controls for fixed width reading
 func NewReader(r io.Reader) *Reader {
-	tmp := &Reader{HasEOL: EOLCRLF, r: bufio.NewReader(r)}
+	cr := &countReader{r: r}
+	tmp := &Reader{HasEOL: EOLCRLF, StrictWidth: true, cr: cr, r: bufio.NewReader(cr)}
 	tmp.Init()
 	return tmp
 }
 
 // error generates a ParseError with necessary information
+// If err already is a ParseError it is returned as is
 func (r *Reader) error(err error) error {
+	if pe, ok := err.(*ParseError); ok {
+		return pe
+	}
 	return &ParseError{Line: r.line, Column: r.column, Err: err}
 }
 
 // parseRecord process a line
+// The record is read and checked by readRecord
+// Then based on the field lengths the fields are extracted and trimmed (if defined).
+func (r *Reader) parseRecord() (fields []string, err error) {
+	tmp, err := r.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	r.lastline = tmp
+	if err = r.verifyChecksum(tmp); err != nil {
+		return nil, err
+	}
+	return r.splitRecord(tmp)
+}
+
+// splitRecord splits a record that has been read and checked into its fields
+func (r *Reader) splitRecord(tmp string) ([]string, error) {
+	var result = make([]string, 0, len(r.offsets))
+	for i, rng := range r.offsets { // For each field extract the information
+		raw := r.LastFieldRaw && i == len(r.offsets)-1 && !r.byWidth()
+		if raw { // The raw last field is the rest of the line
+			rng[1] = len(tmp)
+		}
+		if r.wholeline { // The whole line field is the line without the skips
+			rng[1] = len(tmp) - r.SkipEnd
+		}
+		if r.WidthFor != nil && rng[1] > len(tmp) {
+			// Fields that are not present in a shorter record are empty
+			if rng[0] < len(tmp) {
+				return nil, &ParseError{Line: r.line, Column: i, Err: ErrIncorrectLineWidth}
+			}
+			result = append(result, r.NullField)
+			continue
+		}
+		if r.CheckRuneBoundaries && !onRuneBoundaries(tmp, rng) {
+			return nil, &ParseError{Line: r.line, Column: i, Err: ErrRuneBoundary}
+		}
+		field := string(tmp[rng[0]:rng[1]]) // Extract the field
+		if r.VerifyPadding && !raw && (i >= len(r.FieldEncoding) || r.FieldEncoding[i] == nil) && !r.validPadding(i, field) {
+			return nil, &ParseError{Line: r.line, Column: i, Err: ErrInvalidPadding}
+		}
+		if i < len(r.FieldEncoding) && r.FieldEncoding[i] != nil { // Decode the field before it is trimmed
+			decoded, err := r.FieldEncoding[i].Bytes([]byte(field))
+			if err != nil {
+				return nil, &ParseError{Line: r.line, Column: i, Err: err}
+			}
+			field = string(decoded)
+		}
+		if (r.TrimFields || r.FieldFiller != 0) && !raw { // Remove the filler and spaces and tabs (if fields must be trimmed)
+			leading, trailing := r.trimSides(i)
+			field = trimPad(field, r.FieldFiller, r.TrimFields, leading, trailing)
+		}
+		if i < len(r.FieldTransform) && r.FieldTransform[i] != nil { // Normalize the field before it is checked
+			field = r.FieldTransform[i](field)
+		}
+		if r.CollapseSpaces && !raw {
+			field = CollapseSpaces(field)
+		}
+		if !raw && i < len(r.MaxFieldLen) && r.MaxFieldLen[i] > 0 && len(field) > r.MaxFieldLen[i] {
+			return nil, &ParseError{Line: r.line, Column: i, Err: fmt.Errorf("%w: field %d is %d long, maximum is %d", ErrFieldTooLong, i, len(field), r.MaxFieldLen[i])}
+		}
+		if i < len(r.StripLeadingZeros) && r.StripLeadingZeros[i] {
+			field = stripLeadingZeros(field)
+		}
+		if field == "" && r.NullField != "" { // Empty fields are replaced by the null sentinel (if defined)
+			field = r.NullField
+		}
+		result = append(result, field)
+	}
+	if r.HasLengthField { // The variable segment is the last field
+		result = append(result, tmp[r.width:])
+	}
+	return result, nil
+}
+
+// onRuneBoundaries - check that the field starts and ends on UTF-8 character boundaries,
+// the end of the line (an empty field at the end) is a boundary
+func onRuneBoundaries(line string, rng [2]int) bool {
+	if rng[0] < len(line) && !utf8.RuneStart(line[rng[0]]) {
+		return false
+	}
+	return rng[1] >= len(line) || utf8.RuneStart(line[rng[1]])
+}
+
+// readRecord reads the next record without splitting it into fields
 // First any lines with comments (if comment is defined) are skipped
 // The number of bytes based on the width is then read.
 // If it either is too small or contains a CR or LF an error is returned (because it means the line length is incorrect).
 // If HasEOL is defined and no CR/LF follows it means there are extra characters on the line which is an error
-// Then based on the field lengths the fields are extracted and trimmed (if defined).
-func (r *Reader) parseRecord() (fields []string, err error) {
-	tmp, err := r.readLine()
+func (r *Reader) readRecord() (string, error) {
+	tmp, err := r.readDataLine()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	// Get rid of comment lines
-	if r.Comment != 0 && rune(tmp[0]) == r.Comment {
-		for rune(tmp[0]) == r.Comment {
-			tmp, err = r.readLine()
+	// Join the continuation lines of the record (if defined)
+	if !r.byWidth() {
+		for i := 1; i < r.RecordLines; i++ {
+			more, err := r.readLine()
 			if err != nil {
-				return nil, err
+				if err == io.EOF {
+					return "", ErrNotEnoughLines
+				}
+				return "", err
 			}
+			tmp += more
 		}
 	}
-	if len(tmp) != r.width {
-		return nil, ErrIncorrectLineWidth
+	return r.checkRecord(tmp)
+}
+
+// checkRecord checks the width and contents of a record that has been read
+// and returns it without any trailing bytes (if they are allowed)
+func (r *Reader) checkRecord(tmp string) (string, error) {
+	// Every record must be as long as the first one (if defined)
+	if r.ConsistentWidth {
+		if r.firstwidth == 0 {
+			r.firstwidth = len(tmp)
+		} else if len(tmp) != r.firstwidth {
+			return "", fmt.Errorf("%w: record is %d long but the first record is %d long", ErrIncorrectLineWidth, len(tmp), r.firstwidth)
+		}
 	}
-	for _, val := range tmp {
-		// There shouldn't be any CR or LF chars in the input
-		if val == 13 || val == 10 {
-			fmt.Printf("Contains cr or lf")
-			return nil, ErrIncorrectLineWidth
+	if r.wholeline {
+		// A whole line field can be any length as long as the skips fit
+		if len(tmp) < r.width {
+			return "", ErrIncorrectLineWidth
+		}
+	} else if r.LastFieldRaw && !r.byWidth() {
+		// The last field takes the rest of the line so only the other fields must fit
+		if len(tmp) < r.rawwidth {
+			return "", ErrIncorrectLineWidth
+		}
+	} else {
+		width, err := r.recordWidth(tmp)
+		if err != nil {
+			return "", err
+		}
+		if len(tmp) > width && r.AllowTrailingBytes {
+			tmp = tmp[:width] // Discard anything after the record
+		}
+		if len(tmp) != width && !r.StrictWidth && !r.byWidth() && r.WidthFor == nil && !r.HasLengthField {
+			tmp = r.fitWidth(tmp, width) // Pad or cut a ragged line
+		}
+		if len(tmp) != width {
+			return "", ErrIncorrectLineWidth
 		}
 	}
-	var result = make([]string, 0, len(r.FieldLengths))
-	curpos := r.SkipStart                // Skip the necessary chars in beginning of line prescribed by SkipStart
-	for _, val := range r.FieldLengths { // For each field extract the information
-		field := string(tmp[curpos : curpos+val]) // Extract the field
-		if r.TrimFields {                         // If fields must be trimmed remove any leading and trailing spaces and tabs
-			field = strings.Trim(field, " \t")
+	// There shouldn't be any CR or LF chars in the input, unless the width decides where the record ends.
+	// With a RecordSep or SplitFunc only the chars of the HasEOL delimeter are not allowed
+	if !r.FixedWidthEOL || r.RecordSep != 0 || r.SplitFunc != nil {
+		forbidden := "\r\n"
+		if r.RecordSep != 0 || r.SplitFunc != nil {
+			forbidden = eolChars(r.HasEOL)
+		}
+		if i := strings.IndexAny(tmp, forbidden); i >= 0 {
+			return "", &ParseError{Line: r.line, Column: i, Err: fmt.Errorf("%w: line delimeter inside the record", ErrIncorrectLineWidth)}
 		}
-		curpos += val
-		result = append(result, field)
 	}
-	return result, nil
+	// Only printable ASCII is allowed (if defined)
+	if r.ASCIIOnly {
+		for i := 0; i < len(tmp); i++ {
+			if tmp[i] < 0x20 || tmp[i] > 0x7e {
+				return "", &ParseError{Line: r.line, Column: i, Err: fmt.Errorf("%w: byte 0x%02x", ErrInvalidCharacter, tmp[i])}
+			}
+		}
+	}
+	return tmp, nil
+}
+
+// fitWidth - pad the line with FieldFiller (or spaces) or cut it to width
+func (r *Reader) fitWidth(line string, width int) string {
+	if len(line) > width {
+		return line[:width]
+	}
+	return line + strings.Repeat(string([]byte{r.padByte()}), width-len(line))
+}
+
+// recordWidth - the width the record must have, if WidthFor is defined it decides the width
+// based on the first PrefixLen bytes of the record
+func (r *Reader) recordWidth(tmp string) (int, error) {
+	if r.HasLengthField { // The fixed part is followed by the variable segment
+		n, err := r.segmentLength(tmp)
+		return r.width + n, err
+	}
+	if r.WidthFor == nil {
+		return r.width, nil
+	}
+	if len(tmp) < r.PrefixLen {
+		return 0, ErrIncorrectLineWidth
+	}
+	width := r.WidthFor(tmp[:r.PrefixLen])
+	if width <= 0 || width > r.width {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownRecordType, tmp[:r.PrefixLen])
+	}
+	return width, nil
+}
+
+// nextDataLine - read lines until one is found that is not a comment line (if comment is defined)
+// and not empty (when lines are delimited)
+func (r *Reader) nextDataLine() (string, error) {
+	for {
+		tmp, err := r.readLine()
+		if err != nil {
+			return "", err
+		}
+		if len(tmp) == 0 && (r.HasEOL != EOLNONE || r.RecordSep != 0 || r.SplitFunc != nil) {
+			continue
+		}
+		if r.isComment(tmp) {
+			continue
+		}
+		return tmp, nil
+	}
+}
+
+// isComment - check if the line has the comment rune at CommentOffset (the start of the line by default,
+// SkipStart if CommentAfterSkip is set)
+func (r *Reader) isComment(line string) bool {
+	offset := r.CommentOffset
+	if r.CommentAfterSkip {
+		offset += r.SkipStart
+	}
+	if r.Comment == 0 || r.DisableComment || offset < 0 || len(line) <= offset {
+		return false
+	}
+	return strings.HasPrefix(line[offset:], string(r.Comment))
 }
 
 // skipInitialLines - will only be called once after the definition of Reader
-// it will skip the number of lines defined (if defined)
+// it will skip the number of lines defined (if defined), comment lines are skipped without
+// being counted unless SkipCountsComments is set
 func (r *Reader) skipInitialLines() error {
 	for i := 0; i < r.SkipLines; i++ {
-		_, err := r.readLine()
+		line, err := r.readLine()
 		if err != nil {
 			return err
 		}
+		if !r.SkipCountsComments && r.isComment(line) {
+			i--
+		}
 	}
 	r.initialskipdone = true
 	return nil
 }
 
 // Read will read one line of fields from the input and return it
+// At the end of the input io.EOF is returned, any other error is returned as a ParseError
 func (r *Reader) Read() ([]string, error) {
 	if !r.initialskipdone {
 		err := r.skipInitialLines()
@@ -232,81 +919,524 @@ func (r *Reader) Read() ([]string, error) {
 			return nil, r.error(err)
 		}
 	}
-	return r.parseRecord()
+	record, err := r.parseRecord()
+	if err != nil && err != io.EOF {
+		return nil, r.error(err)
+	}
+	return record, err
 }
 
-// ReadRows read a specified number of rows from the input
-func (r *Reader) ReadRows(numOfRows int) ([][]string, error) {
-	if !r.initialskipdone {
-		err := r.skipInitialLines()
-		if err != nil {
-			return nil, r.error(err)
+// ReadN reads the next record like Read and also returns the number of bytes of the input that were consumed
+// for it, including the line delimeter and any comment, empty or skipped lines in front of it
+func (r *Reader) ReadN() ([]string, int, error) {
+	start := r.Offset()
+	record, err := r.Read()
+	return record, int(r.Offset() - start), err
+}
+
+// Offset returns the number of bytes of the input consumed so far (for a Reader created with NewReader).
+// Processing can be resumed later by seeking the input to the offset and reading it with a new
+// Reader that has the same layout (without SkipLines).
+func (r *Reader) Offset() int64 {
+	if r.peek != nil { // The line read ahead for TrailerLast isn't consumed yet
+		return r.peek.offset
+	}
+	return r.inputOffset()
+}
+
+// inputOffset - the number of bytes of the input read so far that are not buffered
+func (r *Reader) inputOffset() int64 {
+	if r.cr == nil {
+		return 0
+	}
+	return r.cr.n - int64(r.r.Buffered()) - int64(len(r.splitbuf))
+}
+
+// ReadWithFlags reads the next record like Read and also returns a flag for every field that is set
+// if the field consisted only of padding (spaces, tabs or FieldFiller) before it was trimmed or transformed.
+// Fields that are not present in a shorter record (see WidthFor) are flagged as well.
+func (r *Reader) ReadWithFlags() ([]string, []bool, error) {
+	record, err := r.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	blank := make([]bool, len(r.offsets))
+	for i, raw := range r.rawFields()[:len(r.offsets)] {
+		blank[i] = r.isPadding(raw)
+	}
+	return record, blank, nil
+}
+
+// ReadRaw reads the next record like Read and also returns the fields exactly as they are in the input
+// (untrimmed and not converted in any way) so fields that are not changed can be written back byte for byte.
+// Fields that are not present in a shorter record (see WidthFor) are returned as empty raw fields.
+func (r *Reader) ReadRaw() (trimmed []string, raw []string, err error) {
+	trimmed, err = r.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	return trimmed, r.rawFields(), nil
+}
+
+// rawFields - the fields of the last record as they are in the input
+func (r *Reader) rawFields() []string {
+	result := make([]string, 0, len(r.offsets)+1)
+	for i, rng := range r.offsets {
+		if r.LastFieldRaw && i == len(r.offsets)-1 && !r.byWidth() {
+			rng[1] = len(r.lastline)
 		}
+		if r.wholeline {
+			rng[1] = len(r.lastline) - r.SkipEnd
+		}
+		if rng[1] > len(r.lastline) {
+			result = append(result, "")
+			continue
+		}
+		result = append(result, r.lastline[rng[0]:rng[1]])
 	}
-	result := make([][]string, 0, numOfRows)
-	for i := 0; i < numOfRows; i++ {
-		record, err := r.parseRecord()
-		if err != nil {
-			return result, r.error(err)
+	if r.HasLengthField {
+		result = append(result, r.lastline[r.width:])
+	}
+	return result
+}
+
+// isPadding - check if a raw field only contains spaces, tabs or the FieldFiller
+func (r *Reader) isPadding(field string) bool {
+	for i := 0; i < len(field); i++ {
+		if field[i] != ' ' && field[i] != '\t' && (r.FieldFiller == 0 || field[i] != r.FieldFiller) {
+			return false
 		}
-		result = append(result, record)
 	}
-	return result, nil
+	return true
 }
 
-// ReadAll will read all lines from the input
-func (r *Reader) ReadAll() ([][]string, error) {
+// ReadBytes reads the next record like Read but returns the fields as byte slices that refer to an
+// internal buffer of the Reader, so no memory is allocated per field. The slices are only valid until
+// the next record is read and must not be changed or kept, copy them if they are needed longer
+// (the fields of overlapping FieldRanges share their bytes). Only the splitting and trimming are done, per field options like NullField, MaxFieldLen, FieldTransform
+// and FieldEncoding are not applied.
+func (r *Reader) ReadBytes() ([][]byte, error) {
 	if !r.initialskipdone {
 		err := r.skipInitialLines()
 		if err != nil {
 			return nil, r.error(err)
 		}
 	}
-	result := make([][]string, 0)
-	for {
-		record, err := r.parseRecord()
-		if err != nil {
-			if err.Error() == "EOF" {
-				err = nil
+	tmp, err := r.readRecord()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, r.error(err)
+	}
+	r.lastline = tmp
+	r.linebuf = append(r.linebuf[:0], tmp...)
+	r.fieldbuf = r.fieldbuf[:0]
+	for i, rng := range r.offsets {
+		raw := r.LastFieldRaw && i == len(r.offsets)-1 && !r.byWidth()
+		if raw { // The raw last field is the rest of the line
+			rng[1] = len(r.linebuf)
+		}
+		if r.wholeline {
+			rng[1] = len(r.linebuf) - r.SkipEnd
+		}
+		if rng[1] > len(r.linebuf) { // Fields that are not present in a shorter record (WidthFor) are empty
+			if rng[0] < len(r.linebuf) {
+				return nil, &ParseError{Line: r.line, Column: i, Err: ErrIncorrectLineWidth}
 			}
-			return result, err
+			r.fieldbuf = append(r.fieldbuf, r.linebuf[len(r.linebuf):len(r.linebuf)])
+			continue
 		}
-		result = append(result, record)
+		field := r.linebuf[rng[0]:rng[1]:rng[1]]
+		if (r.TrimFields || r.FieldFiller != 0) && !raw {
+			leading, trailing := r.trimSides(i)
+			field = trimPad(field, r.FieldFiller, r.TrimFields, leading, trailing)
+		}
+		r.fieldbuf = append(r.fieldbuf, field)
+	}
+	if r.HasLengthField {
+		r.fieldbuf = append(r.fieldbuf, r.linebuf[r.width:len(r.linebuf):len(r.linebuf)])
 	}
+	return r.fieldbuf, nil
 }
 
-// Writer is used to control the writing to the output stream
-//   Comment - if defined it is used to indicate a comment line starting with this rune
-//   SkipStart - indicates the number of spaces to write before rest of columns are written)
-//   SkipEnd - indicate how many spaces at the end of eache line to add
-//   TrimFields - if set all fields are trimmed if they are too big else an error is returned
-//   HasEOL - indicates that a CRLF must be added to each line
-//   FieldLengths - is a slice with the lengths of the fields
+// Scan reads the next record so that it is available through Record, in the same way as bufio.Scanner.
+// It returns false at the end of the input or when an error occurred, Err then returns the error (if any).
+func (r *Reader) Scan() bool {
+	if r.scanerr != nil {
+		return false
+	}
+	r.record, r.scanerr = r.Read()
+	return r.scanerr == nil
+}
+
+// Record returns the record read by the last call to Scan
+func (r *Reader) Record() []string {
+	return r.record
+}
+
+// Err returns the error that stopped Scan, at the end of the input it returns nil
+func (r *Reader) Err() error {
+	if r.scanerr == io.EOF {
+		return nil
+	}
+	return r.scanerr
+}
+
+// ReadRows read a specified number of rows from the input
+func (r *Reader) ReadRows(numOfRows int) ([][]string, error) {
+	return r.ReadRowsContext(context.Background(), numOfRows)
+}
+
+// ReadRowsContext is the same as ReadRows but stops when ctx is cancelled,
+// in which case the rows read so far and the error of the context are returned
+func (r *Reader) ReadRowsContext(ctx context.Context, numOfRows int) ([][]string, error) {
+	if !r.initialskipdone {
+		err := r.skipInitialLines()
+		if err != nil {
+			return nil, r.error(err)
+		}
+	}
+	done := ctx.Done()
+	result := make([][]string, 0, numOfRows)
+	for i := 0; i < numOfRows; i++ {
+		if cancelled(done) {
+			return result, ctx.Err()
+		}
+		record, err := r.parseRecord()
+		if err != nil {
+			return result, r.error(err)
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// ReadExactly reads n records and checks that they are the last records of the input.
+// If there are fewer records ErrNotEnoughLines is returned (with the records read) and if there are
+// more ErrTooManyLines is returned, in which case the extra record has been consumed.
+func (r *Reader) ReadExactly(n int) ([][]string, error) {
+	result, err := r.ReadRows(n)
+	if errors.Is(err, io.EOF) {
+		return result, r.error(ErrNotEnoughLines)
+	}
+	if err != nil {
+		return result, err
+	}
+	_, err = r.parseRecord()
+	if err == io.EOF {
+		return result, nil
+	}
+	if err == nil {
+		err = ErrTooManyLines
+	}
+	return result, r.error(err)
+}
+
+// ReadPage skips offset records and then returns up to limit records (counted from the current position).
+// The skipped records are checked but not split into fields. If the input ends first the records
+// read so far are returned without an error.
+func (r *Reader) ReadPage(offset, limit int) ([][]string, error) {
+	if !r.initialskipdone {
+		err := r.skipInitialLines()
+		if err != nil {
+			return nil, r.error(err)
+		}
+	}
+	for i := 0; i < offset; i++ {
+		if _, err := r.readRecord(); err != nil {
+			if err == io.EOF {
+				return [][]string{}, nil
+			}
+			return nil, r.error(err)
+		}
+	}
+	result := make([][]string, 0, limit)
+	for i := 0; i < limit; i++ {
+		record, err := r.parseRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, r.error(err)
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// ReadGroup reads a group of consecutive records that together form one entity (for example a header
+// followed by its detail lines). sizes gives the number of records of every part of the group and the
+// fields of the records of a part are combined into one entry of the result, so sizes of {1, 2} returns
+// the header and the fields of the two detail records joined.
+// io.EOF is returned if the input ends before the group starts and ErrNotEnoughLines if it ends inside the group.
+func (r *Reader) ReadGroup(sizes []int) ([][]string, error) {
+	result := make([][]string, 0, len(sizes))
+	started := false
+	for _, size := range sizes {
+		var part []string
+		for i := 0; i < size; i++ {
+			record, err := r.Read()
+			if err == io.EOF && started {
+				return nil, r.error(ErrNotEnoughLines)
+			}
+			if err != nil {
+				return nil, err
+			}
+			started = true
+			part = append(part, record...)
+		}
+		result = append(result, part)
+	}
+	return result, nil
+}
+
+// ReadAll will read all lines from the input
+func (r *Reader) ReadAll() ([][]string, error) {
+	return r.ReadAllContext(context.Background())
+}
+
+// ReadAllContext is the same as ReadAll but stops when ctx is cancelled,
+// in which case the rows read so far and the error of the context are returned
+func (r *Reader) ReadAllContext(ctx context.Context) ([][]string, error) {
+	if !r.initialskipdone {
+		err := r.skipInitialLines()
+		if err != nil {
+			return nil, r.readAllError(err)
+		}
+	}
+	done := ctx.Done()
+	result := make([][]string, 0)
+	for {
+		if cancelled(done) {
+			return result, ctx.Err()
+		}
+		record, err := r.parseRecord()
+		if err != nil {
+			if err == io.EOF {
+				return result, nil
+			}
+			return result, r.readAllError(err)
+		}
+		if r.OnRecord != nil {
+			r.OnRecord(r.line, record)
+		}
+		result = append(result, record)
+	}
+}
+
+// readAllError - convert err to a ParseError and pass it to OnError (if defined)
+func (r *Reader) readAllError(err error) error {
+	pe := r.error(err).(*ParseError)
+	if r.OnError != nil {
+		r.OnError(pe)
+	}
+	return pe
+}
+
+// cancelled - check without blocking if the done channel of a context is closed
+// a nil channel (context that can never be cancelled) is never checked
+func cancelled(done <-chan struct{}) bool {
+	if done == nil {
+		return false
+	}
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// LastEOL returns the line delimeter (EOLCR, EOLLF or EOLCRLF) that ended the last line read,
+// EOLNONE is returned if no line delimeter was consumed (for example with EOLNONE or for the last line of the input)
+func (r *Reader) LastEOL() int {
+	return r.lasteol
+}
+
+// LastLine returns the raw line of the record that was parsed last (before it was split and trimmed).
+// It is only valid until the next record is read.
+func (r *Reader) LastLine() string {
+	return r.lastline
+}
+
+// Validate reads the rest of the input and runs all the checks of Read on every record (the width, checksum and
+// field checks) without keeping the fields. The first error found is returned or nil if the whole input is valid.
+func (r *Reader) Validate() error {
+	if !r.initialskipdone {
+		err := r.skipInitialLines()
+		if err != nil {
+			return r.error(err)
+		}
+	}
+	for {
+		_, err := r.parseRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return r.error(err)
+		}
+	}
+}
+
+// WriteTo copies the remaining raw bytes of the input (including what is already buffered) to w
+// and returns the number of bytes copied. Records already returned by Read are not copied again.
+// With TrailerLast the line read ahead can't be copied, so ErrInvalidLayout is returned once a record has been read.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	if r.peek != nil && r.peek.err == nil {
+		return 0, fmt.Errorf("%w: the line read ahead for TrailerLast can't be copied", ErrInvalidLayout)
+	}
+	var n int64
+	if len(r.splitbuf) > 0 { // The input SplitFunc has been given but didn't use yet
+		m, err := w.Write(r.splitbuf)
+		n += int64(m)
+		r.splitbuf = r.splitbuf[m:]
+		if err != nil {
+			return n, err
+		}
+	}
+	m, err := r.r.WriteTo(w)
+	n += m
+	if err == nil && r.SplitFunc != nil { // Everything is copied, there are no records left for SplitFunc
+		r.splitbuf, r.spliteof, r.splitdone = nil, true, true
+	}
+	return n, err
+}
+
+// Writer is used to control the writing to the output stream
+//   Comment - if defined it is used to indicate a comment line starting with this rune
+//   SkipStart - indicates the number of spaces to write before rest of columns are written)
+//   SkipEnd - indicate how many spaces at the end of eache line to add
+//   TrimFields - if set all fields are trimmed if they are too big else an error is returned
+//   HasEOL - the line delimeter added to each line: EOLNONE, EOLCR (the default of NewWriter), EOLLF or EOLCRLF
+//   FieldLengths - is a slice with the lengths of the fields
 //   FieldAlign - is a slice that contains the individual alignment of each field
+//   FieldNames - optional names of the fields (as loaded from a Layout)
+//   NDJSON - if set WriteJSON expects one JSON object per line instead of a JSON array
+//   ExpectedWidth - if defined Init checks that the skips and field lengths add up to this width
+//   ClampSkips - if set a negative SkipStart or SkipEnd is set to 0 by Init instead of returning ErrNegativeSkip
+//   NullField - if defined a field with this value is written as padding only
+//   BlockSize - if defined Close pads the output to a multiple of this size
+//   BlockPad - the byte used by Close to pad the output to BlockSize (a space by default)
+//   PadChar - the character used to pad fields to their length (a space if not defined), it should be a single byte character
+//   Prefix - constant text written in the SkipStart region (padded with spaces), it may not be longer than SkipStart
+//   Suffix - constant text written in the SkipEnd region (padded with spaces), it may not be longer than SkipEnd
+//   WriteBOM - if set the UTF-8 byte order mark is written once before the first record or comment
+//   DisplayWidths - if set the width of a value is the number of columns it takes up on a terminal instead
+//     of the number of bytes, tabs advance to the next tab stop (counted from the start of the value)
+//   TabWidth - the distance between tab stops when DisplayWidths is set (8 if not defined)
+//   EastAsianWidths - if set with DisplayWidths East Asian wide characters take up two columns
+//   SequenceColumn - the index of a field that is filled with the 1-based record number (zero padded),
+//     whatever value is given for it is ignored. -1 (the default) disables it. A number that doesn't fit in the
+//     field is always an error (TrimFields and TrimOverflow don't apply)
+//   TrimOverflow - optional per field override of TrimFields, it must have an entry for every field
+//   TimeLayout - the layout used by WriteTyped to format a time.Time (time.RFC3339 if not defined)
+//   FieldDateLayouts - optional layout per field used by WriteTyped to format a time.Time
+//   ContinueOnError - if set WriteAll writes all the records it can and returns the errors of those that failed combined
+//   FieldEncoding - optional Encoder per field (nil to write the value as is) that encodes the value before it is
+//     aligned, for example PackedEncoder for packed decimal (COMP-3) fields
+//   PadComments - if set comment lines are padded with spaces to the record width (set by NewWriter)
+//   FieldDecimals - the number of positions after the decimal point of each ALIGNDECIMAL field (0 if not defined),
+//     the point itself takes up one more position
+//   LeadingEOL - if set a line delimeter is written before the first line (after the byte order mark),
+//     useful when the output is appended to a file that doesn't end with one
+//   ChecksumFunc - if defined it is called with the formatted record (after PostFormat) without the ChecksumColumn
+//     field and the result is written in that field, whatever value is given for it is ignored (for example LRCHex).
+//     The record must be complete to compute it, so WriteField and EndRecord return ErrInvalidLayout when it is set
+//   ChecksumColumn - the index of the field that is filled with the result of ChecksumFunc
+//   GroupSize - if more than 1 the line delimeter is only written after every GroupSize records, so the records of
+//     a group are joined on one line. Flush, Close and comment lines end a partial group
+//   PostFormat - optional hook that gets the complete formatted line of every record (without the EOL) and returns
+//     the line that is written instead. It must keep the width if the output is read as fixed width again.
+//     It isn't used for records written with WriteField
+//   StripControls - if set control characters (like CR, LF and tab) are removed from values before they are measured,
+//     so a stray line delimeter in a value can't break the record. Off by default
+//   SanitizeFunc - optional function applied to every value before it is measured (instead of StripControls),
+//     for example to replace Windows-1252 smart quotes. With WrapOverflow the parts of a wrapped value are sanitized
+//     again when they are written, so applying it twice must give the same result
+//   WrapOverflow - if set a value that is too long for its field is continued on extra records (written by Write and
+//     WriteAligned) with the rest of the value in the same field and all the other fields empty (padding only).
+//     SequenceColumn numbers every extra record. Fields with a FieldEncoding, ALIGNDECIMAL fields, the sequence and
+//     checksum fields and fields that can't hold a single character are not wrapped (TrimFields applies to them)
+//   FieldDefaults - the values used by WriteMap for the names that are not in the map, a field without a default
+//     must be in the map
+//   PassthroughExactWidth - if set a value that is already exactly as wide as its field is written as is, so
+//     pre-formatted values can be mixed with raw ones. Fields with a FieldEncoding or aligned on the decimal point
+//     are always formatted. StripControls and SanitizeFunc are still applied first
 type Writer struct {
-	Comment      rune
-	SkipStart    int
-	SkipEnd      int
-	FieldLengths []int
-	FieldAlign   []int
-	HasEOL       int
-	TrimFields   bool
-	width        int
-	line         int
-	column       int
-	w            *bufio.Writer
+	Comment               rune
+	SkipStart             int
+	SkipEnd               int
+	FieldLengths          []int
+	FieldAlign            []int
+	FieldNames            []string
+	HasEOL                int
+	TrimFields            bool
+	NDJSON                bool
+	ExpectedWidth         int
+	ClampSkips            bool
+	NullField             string
+	BlockSize             int
+	BlockPad              byte
+	PadChar               rune
+	Prefix                string
+	Suffix                string
+	WriteBOM              bool
+	DisplayWidths         bool
+	TabWidth              int
+	EastAsianWidths       bool
+	SequenceColumn        int
+	TrimOverflow          []bool
+	TimeLayout            string
+	FieldDateLayouts      []string
+	ContinueOnError       bool
+	FieldEncoding         []Encoder
+	PadComments           bool
+	FieldDecimals         []int
+	LeadingEOL            bool
+	ChecksumFunc          func([]byte) string
+	ChecksumColumn        int
+	GroupSize             int
+	PostFormat            func(record string) string
+	StripControls         bool
+	SanitizeFunc          func(string) string
+	WrapOverflow          bool
+	FieldDefaults         map[string]string
+	PassthroughExactWidth bool
+	width                 int
+	line                  int
+	column                int
+	nextfield             int
+	inrecord              bool
+	closed                bool
+	initdone              bool
+	bomdone               bool
+	seq                   int
+	ingroup               int
+	errs                  []*RecordError
+	cw                    *countWriter
+	w                     *bufio.Writer
 }
 
 // Init updates width before everyline seeing that output
 // can have different lines and thus the details can differ
+// Init must be called after the layout is changed, Write only calls it if it never succeeded
+// or if FieldAlign doesn't match FieldLengths. FieldAlign is then fitted to the new FieldLengths
 func (r *Writer) Init() error {
-	if r.SkipStart < 0 {
-		r.SkipStart = 0
-	}
-	if r.SkipEnd < 0 {
-		r.SkipEnd = 0
+	r.initdone = false
+	if r.SkipStart < 0 || r.SkipEnd < 0 {
+		if !r.ClampSkips {
+			return ErrNegativeSkip
+		}
+		// Negative skips are treated as 0 if clamping was asked for
+		if r.SkipStart < 0 {
+			r.SkipStart = 0
+		}
+		if r.SkipEnd < 0 {
+			r.SkipEnd = 0
+		}
 	}
-	r.width = r.SkipStart + r.SkipEnd
 	if len(r.FieldLengths) == 0 {
 		return ErrNoFields
 	}
@@ -314,21 +1444,75 @@ func (r *Writer) Init() error {
 		if val <= 0 {
 			return ErrFieldLengthError
 		}
-		r.width += val
 	}
-	// Create default alignment if none was defined
-	if r.FieldAlign == nil {
-		r.FieldAlign = make([]int, len(r.FieldLengths))
-		for i := 0; i < len(r.FieldAlign); i++ {
-			r.FieldAlign[i] = ALIGNLEFT
+	r.width = RequiredWidth(r.FieldLengths, r.SkipStart, r.SkipEnd)
+	// Check the layout against the expected width (if defined)
+	if r.ExpectedWidth > 0 && r.width != r.ExpectedWidth {
+		return fmt.Errorf("%w: expected width %d but layout is %d", ErrFieldLengthError, r.ExpectedWidth, r.width)
+	}
+	// Create default alignment if none was defined (or fit it to the fields)
+	r.FieldAlign = fitAlign(r.FieldAlign, len(r.FieldLengths))
+	if r.TrimOverflow != nil && len(r.TrimOverflow) != len(r.FieldLengths) {
+		return ErrFieldCount
+	}
+	// The decimal point and the decimals must fit into the field
+	for i := 0; i < len(r.FieldDecimals) && i < len(r.FieldLengths); i++ {
+		if r.FieldDecimals[i] < 0 || (r.FieldDecimals[i] > 0 && r.FieldDecimals[i] >= r.FieldLengths[i]) {
+			return fmt.Errorf("%w: field %d has %d decimals but is %d long", ErrFieldLengthError, i, r.FieldDecimals[i], r.FieldLengths[i])
 		}
 	}
+	// The prefix and suffix must fit into the skip regions
+	if len(r.Prefix) > r.SkipStart {
+		return fmt.Errorf("%w: prefix is %d long but SkipStart is %d", ErrFieldLengthError, len(r.Prefix), r.SkipStart)
+	}
+	if len(r.Suffix) > r.SkipEnd {
+		return fmt.Errorf("%w: suffix is %d long but SkipEnd is %d", ErrFieldLengthError, len(r.Suffix), r.SkipEnd)
+	}
+	r.initdone = true
 	return nil
 }
 
+// RequiredWidth returns the width of a record (without the line delimeter) with the given field lengths and skips,
+// the same width Init of a Reader or Writer uses for that layout. The lengths are not checked.
+func RequiredWidth(fieldLengths []int, skipStart, skipEnd int) int {
+	width := skipStart + skipEnd
+	for _, val := range fieldLengths {
+		width += val
+	}
+	return width
+}
+
+// fitAlign - return the alignments for n fields, alignments that are missing are ALIGNLEFT
+// and any alignments past the last field are dropped
+func fitAlign(align []int, n int) []int {
+	if len(align) == n {
+		return align
+	}
+	result := make([]int, n)
+	for i := range result {
+		result[i] = ALIGNLEFT
+		if i < len(align) {
+			result[i] = align[i]
+		}
+	}
+	return result
+}
+
+// checkInit - run Init if it was never successfully run (for example when FieldLengths was set after NewWriter)
+// or if the alignments don't match the fields anymore
+func (w *Writer) checkInit() error {
+	if w.initdone && len(w.FieldAlign) == len(w.FieldLengths) {
+		return nil
+	}
+	return w.Init()
+}
+
 // NewWriter returns a struct with the controls for fixed width writing
+// NOTE: the default line delimeter is EOLCR (a CR only, not LF or CRLF), set HasEOL or use NewWriterEOL
+// to write other line endings
 func NewWriter(w io.Writer) *Writer {
-	tmp := &Writer{HasEOL: EOLCR, w: bufio.NewWriter(w)}
+	cw := &countWriter{w: w}
+	tmp := &Writer{HasEOL: EOLCR, BlockPad: ' ', SequenceColumn: -1, PadComments: true, cw: cw, w: bufio.NewWriter(cw)}
 	tmp.Init()
 	return tmp
 }
@@ -339,11 +1523,21 @@ func (w *Writer) error(err error) error {
 }
 
 // outputSpaces will send a specific number of spaces to the output
-func (w *Writer) outputSpaces(n int) {
+func (w *Writer) outputSpaces(n int) error {
 	for n > 0 {
-		w.w.WriteByte(' ')
+		if err := w.w.WriteByte(' '); err != nil {
+			return err
+		}
 		n--
 	}
+	return nil
+}
+
+// NewWriterEOL returns a Writer like NewWriter that ends lines with eol (EOLNONE, EOLCR, EOLLF or EOLCRLF)
+func NewWriterEOL(w io.Writer, eol int) *Writer {
+	tmp := NewWriter(w)
+	tmp.HasEOL = eol
+	return tmp
 }
 
 // Write will first output the defined number of spaces at the front (SkipStart)
@@ -351,99 +1545,722 @@ func (w *Writer) outputSpaces(n int) {
 // then the fields are output (trimmed if need be) and then any trailing spaces are added (if SkipEnd is defined)
 // If HasEOL is defined CR and LF will be send to output
 func (w *Writer) Write(flds []string) error {
-	w.outputSpaces(w.SkipStart)
+	return w.WriteAligned(flds, nil)
+}
+
+// WriteAligned writes a record like Write but with the alignment of every field given by align instead
+// of FieldAlign (which is used if align is nil), so the alignment can differ per record
+func (w *Writer) WriteAligned(flds []string, align []int) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if w.WrapOverflow {
+		return w.writeWrapped(flds, align)
+	}
+	line, err := w.formatAligned(flds, align)
+	if err != nil {
+		return err
+	}
+	if err = w.writeBOM(); err != nil {
+		return err
+	}
+	if _, err = w.w.Write(line); err != nil {
+		return err
+	}
+	if err = w.writeRecordEOL(); err != nil {
+		return err
+	}
+	w.line++
+	w.seq++
+	return nil
+}
+
+// writeWrapped writes the record and the continuation records for the values that overflow their fields.
+// All the lines are formatted before any is written, so nothing is written if one of them fails
+func (w *Writer) writeWrapped(flds []string, align []int) error {
+	lines, err := w.formatWrapped(flds, align)
+	if err != nil {
+		return err
+	}
+	if err = w.writeBOM(); err != nil {
+		return err
+	}
+	for _, out := range lines {
+		if _, err = w.w.Write(out); err != nil {
+			return err
+		}
+		if err = w.writeRecordEOL(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatWrapped - format the record and its continuation records, the line and sequence numbers are
+// advanced for every record (they are left as they were if an error is returned)
+func (w *Writer) formatWrapped(flds []string, align []int) ([][]byte, error) {
+	if err := w.checkInit(); err != nil {
+		return nil, err
+	}
 	if len(flds) != len(w.FieldLengths) {
-		return ErrFieldCount
+		return nil, ErrFieldCount
 	}
-	for i := 0; i < len(flds); i++ {
-		buf := []byte(flds[i])
-		var n int
-		var err error
-		if len(buf) > w.FieldLengths[i] {
-			if !w.TrimFields {
-				return ErrFieldLengthError
-			}
-			n, err = w.w.Write(buf[0:w.FieldLengths[i]])
-			if err != nil {
-				return err
-			}
-			if n != w.FieldLengths[i] {
-				return ErrFieldLengthError
+	line, seq := w.line, w.seq
+	var lines [][]byte
+	for rest := flds; rest != nil; {
+		var cur []string
+		cur, rest = w.wrapFields(rest, align)
+		out, err := w.formatAligned(cur, align)
+		if err != nil {
+			w.line, w.seq = line, seq
+			return nil, err
+		}
+		lines = append(lines, out)
+		w.line++ // Keep the line and sequence numbers right for the next continuation record
+		w.seq++
+	}
+	return lines, nil
+}
+
+// wrapFields - split the values in the part that fits into the fields and the rest that is continued on the next
+// record (nil if everything fits)
+func (w *Writer) wrapFields(flds []string, align []int) (cur, rest []string) {
+	if align == nil {
+		align = w.FieldAlign
+	}
+	cur = make([]string, len(flds))
+	copy(cur, flds)
+	for i, fld := range flds {
+		if i == w.SequenceColumn || (w.ChecksumFunc != nil && i == w.ChecksumColumn) ||
+			(i < len(w.FieldEncoding) && w.FieldEncoding[i] != nil) || (i < len(align) && align[i] == ALIGNDECIMAL) ||
+			(w.NullField != "" && fld == w.NullField) {
+			continue
+		}
+		fld = w.sanitize(fld) // Measure and split the value as it is written
+		if w.valueWidth(fld) <= w.FieldLengths[i] {
+			continue
+		}
+		head, _ := w.truncateValue(fld, w.FieldLengths[i])
+		if head == "" { // Nothing fits (zero width or a wide first character), leave it to TrimFields to avoid looping
+			continue
+		}
+		if rest == nil {
+			rest = make([]string, len(flds))
+		}
+		cur[i], rest[i] = head, fld[len(head):]
+	}
+	return cur, rest
+}
+
+// FormatRecord returns the record as it would be written by Write (without the EOL) without writing it
+func (w *Writer) FormatRecord(flds []string) (string, error) {
+	line, err := w.formatRecord(flds)
+	if err != nil {
+		return "", err
+	}
+	return string(line), nil
+}
+
+// FormatAll returns the records as they would be written by Write (with their line delimeters) in one string
+// without buffering or writing them, the records are numbered (SequenceColumn) from the current record on
+// but the Writer is left as it was. The first error is returned as Write would return it.
+func (w *Writer) FormatAll(recs [][]string) (string, error) {
+	line, seq := w.line, w.seq
+	defer func() { w.line, w.seq = line, seq }()
+	var sb strings.Builder
+	n := 0
+	for _, rec := range recs {
+		var lines [][]byte
+		if w.WrapOverflow {
+			var err error
+			if lines, err = w.formatWrapped(rec, nil); err != nil {
+				return "", err
 			}
 		} else {
-			n = len(buf)
-			// Add spaces in front if aligned right
-			if w.FieldAlign[i] == ALIGNRIGHT {
-				w.outputSpaces(w.FieldLengths[i] - n)
-			}
-			_, err = w.w.Write(buf)
+			out, err := w.formatAligned(rec, nil)
 			if err != nil {
-				return err
+				return "", err
 			}
-			if n != len(buf) {
-				return ErrFieldLengthError
-			}
-			// Add spaces at back if aligned left
-			if w.FieldAlign[i] == ALIGNLEFT {
-				w.outputSpaces(w.FieldLengths[i] - n)
+			lines = [][]byte{out}
+			w.line++
+			w.seq++
+		}
+		for _, out := range lines {
+			sb.Write(out)
+			n++
+			if w.GroupSize <= 1 || n%w.GroupSize == 0 {
+				sb.WriteString(eolChars(w.HasEOL))
 			}
 		}
 	}
-	w.outputSpaces(w.SkipEnd)
+	if w.GroupSize > 1 && n%w.GroupSize != 0 { // End the partial group
+		sb.WriteString(eolChars(w.HasEOL))
+	}
+	return sb.String(), nil
+}
+
+// formatRecord builds the complete line for a record (without the EOL)
+func (w *Writer) formatRecord(flds []string) ([]byte, error) {
+	return w.formatAligned(flds, nil)
+}
+
+// formatAligned builds the complete line for a record with the given alignments (FieldAlign if nil)
+func (w *Writer) formatAligned(flds []string, align []int) ([]byte, error) {
+	if err := w.checkInit(); err != nil {
+		return nil, err
+	}
+	if align == nil {
+		align = w.FieldAlign
+	}
+	if len(flds) != len(w.FieldLengths) || len(align) != len(w.FieldLengths) {
+		return nil, ErrFieldCount
+	}
+	line := make([]byte, 0, w.width)
+	line = appendMargin(line, w.Prefix, w.SkipStart)
+	checksum := [2]int{-1, -1} // Range of the checksum field, it is filled in once the rest of the record is known
+	for i := 0; i < len(flds); i++ {
+		start := len(line)
+		value := flds[i]
+		if w.ChecksumFunc != nil && i == w.ChecksumColumn {
+			value = "" // Only a placeholder
+		}
+		var err error
+		if line, err = w.appendAligned(line, i, value, align[i]); err != nil {
+			return nil, err
+		}
+		if w.ChecksumFunc != nil && i == w.ChecksumColumn {
+			checksum = [2]int{start, len(line)}
+		}
+	}
+	line = appendMargin(line, w.Suffix, w.SkipEnd)
+	width := len(line)
+	if w.PostFormat != nil {
+		line = []byte(w.PostFormat(string(line)))
+	}
+	if checksum[0] >= 0 {
+		if len(line) != width {
+			return nil, &ParseError{Line: w.line + 1, Column: w.ChecksumColumn, Err: fmt.Errorf("%w: PostFormat changed the width from %d to %d so the checksum field can't be found", ErrIncorrectLineWidth, width, len(line))}
+		}
+		var err error
+		if line, err = w.checksumLine(line, checksum, align[w.ChecksumColumn]); err != nil {
+			return nil, err
+		}
+	}
+	return line, nil
+}
+
+// writeField outputs a single field aligned (or trimmed) to the length of the field
+func (w *Writer) writeField(i int, fld string) error {
+	buf, err := w.appendField(nil, i, fld)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(buf)
+	return err
+}
+
+// appendField appends a single field aligned (or trimmed) to the length of the field to line
+func (w *Writer) appendField(line []byte, i int, fld string) ([]byte, error) {
+	return w.appendAligned(line, i, fld, w.FieldAlign[i])
+}
+
+// appendAligned appends a single field with the alignment align (or trimmed) to the length of the field to line
+func (w *Writer) appendAligned(line []byte, i int, fld string, align int) ([]byte, error) {
+	if w.NullField != "" && fld == w.NullField { // The null sentinel is written as padding only
+		fld = ""
+	}
+	if i == w.SequenceColumn { // The sequence column is replaced by the record number
+		fld = fmt.Sprintf("%0*d", w.FieldLengths[i], w.seq+1)
+		if len(fld) > w.FieldLengths[i] { // Cutting it would repeat numbers, so TrimFields doesn't apply
+			return nil, &ParseError{Line: w.line + 1, Column: i, Err: fmt.Errorf("%w: sequence number %d doesn't fit in %d positions", ErrFieldLengthError, w.seq+1, w.FieldLengths[i])}
+		}
+	}
+	fld = w.sanitize(fld)
+	hasEncoding := i < len(w.FieldEncoding) && w.FieldEncoding[i] != nil
+	if w.PassthroughExactWidth && !hasEncoding && align != ALIGNDECIMAL && w.valueWidth(fld) == w.FieldLengths[i] {
+		return append(line, fld...), nil // A pre-formatted value is kept as is
+	}
+	if hasEncoding { // Encode the value before it is measured
+		encoded, err := w.FieldEncoding[i].Bytes([]byte(fld))
+		if err != nil {
+			return nil, &ParseError{Line: w.line + 1, Column: i, Err: err}
+		}
+		fld = string(encoded)
+	}
+	if align == ALIGNDECIMAL {
+		return w.appendDecimal(line, i, fld)
+	}
+	n := w.valueWidth(fld)
+	if n > w.FieldLengths[i] {
+		if !w.trimField(i) {
+			return nil, &ParseError{Line: w.line + 1, Column: i, Err: fmt.Errorf("%w: field %d is %d long, width is %d: %s", ErrFieldLengthError, i, n, w.FieldLengths[i], preview(fld))}
+		}
+		fld, n = w.truncateValue(fld, w.FieldLengths[i])
+	}
+	// An empty value is only spaces if aligned right with blanks
+	if align == ALIGNRIGHTBLANK && fld == "" {
+		return appendSpaces(line, w.FieldLengths[i]), nil
+	}
+	// Add padding in front if aligned right
+	if align == ALIGNRIGHT || align == ALIGNRIGHTBLANK {
+		line = appendPad(line, w.FieldLengths[i]-n, w.PadChar)
+	}
+	line = append(line, fld...)
+	// Add padding at back if aligned left
+	if align == ALIGNLEFT {
+		line = appendPad(line, w.FieldLengths[i]-n, w.PadChar)
+	}
+	return line, nil
+}
+
+// previewLen is the number of characters shown from the start and the end of a long value in an error
+const previewLen = 8
+
+// preview - the value quoted for an error message, a long value is shortened to its first and last
+// previewLen characters so a huge value doesn't end up in the logs
+func preview(fld string) string {
+	runes := []rune(fld)
+	if len(runes) <= 2*previewLen+3 {
+		return strconv.Quote(fld)
+	}
+	return strconv.Quote(string(runes[:previewLen])) + "..." + strconv.Quote(string(runes[len(runes)-previewLen:]))
+}
+
+// trimField - check if the field must be truncated when it is too long, TrimOverflow overrides TrimFields
+func (w *Writer) trimField(i int) bool {
+	if i < len(w.TrimOverflow) {
+		return w.TrimOverflow[i]
+	}
+	return w.TrimFields
+}
+
+// appendDecimal appends a number aligned on its decimal point, the integer part is right aligned in front
+// of the point and the fraction is left aligned in the FieldDecimals positions after it
+func (w *Writer) appendDecimal(line []byte, i int, fld string) ([]byte, error) {
+	decimals := 0
+	if i < len(w.FieldDecimals) {
+		decimals = w.FieldDecimals[i]
+	}
+	intwidth := w.FieldLengths[i] // Room in front of the decimal point
+	if decimals > 0 {
+		intwidth -= decimals + 1
+	}
+	intpart, frac, point := strings.Cut(fld, ".")
+	if len(intpart) > intwidth || len(frac) > decimals {
+		if !w.trimField(i) {
+			return nil, &ParseError{Line: w.line + 1, Column: i, Err: fmt.Errorf("%w: field %d value %s doesn't fit %d.%d positions", ErrFieldLengthError, i, preview(fld), intwidth, decimals)}
+		}
+		if len(intpart) > intwidth {
+			intpart = intpart[:intwidth]
+		}
+		if len(frac) > decimals {
+			frac = frac[:decimals]
+		}
+	}
+	line = appendPad(line, intwidth-len(intpart), w.PadChar)
+	line = append(line, intpart...)
+	if decimals > 0 {
+		if point {
+			line = append(line, '.')
+		} else {
+			line = appendPad(line, 1, w.PadChar)
+		}
+		line = append(line, frac...)
+		line = appendPad(line, decimals-len(frac), w.PadChar)
+	}
+	return line, nil
+}
+
+// appendSpaces appends a specific number of spaces to line
+func appendSpaces(line []byte, n int) []byte {
+	return appendPad(line, n, ' ')
+}
+
+// appendMargin appends text followed by spaces up to a width of n
+func appendMargin(line []byte, text string, n int) []byte {
+	line = append(line, text...)
+	return appendSpaces(line, n-len(text))
+}
+
+// appendPad appends n pad characters to line (a space if pad is 0)
+func appendPad(line []byte, n int, pad rune) []byte {
+	if pad == 0 {
+		pad = ' '
+	}
+	for ; n > 0; n-- {
+		line = utf8.AppendRune(line, pad)
+	}
+	return line
+}
+
+// writeBOM outputs the UTF-8 byte order mark (if WriteBOM is set) and a line delimeter (if LeadingEOL is set)
+// before anything else is written
+func (w *Writer) writeBOM() error {
+	if w.bomdone {
+		return nil
+	}
+	w.bomdone = true
+	if w.WriteBOM {
+		if _, err := w.w.WriteString(utf8BOM); err != nil {
+			return err
+		}
+	}
+	if w.LeadingEOL {
+		return w.writeEOL()
+	}
+	return nil
+}
+
+// writeRecordEOL outputs the line delimeter after a record, with GroupSize only after every GroupSize records
+func (w *Writer) writeRecordEOL() error {
+	w.ingroup++
+	if w.GroupSize <= 1 || w.ingroup >= w.GroupSize {
+		w.ingroup = 0
+		return w.writeEOL()
+	}
+	return nil
+}
+
+// endGroup outputs the line delimeter after a partial group of records (if any)
+func (w *Writer) endGroup() error {
+	if w.ingroup > 0 {
+		w.ingroup = 0
+		return w.writeEOL()
+	}
+	return nil
+}
+
+// writeEOL outputs the line delimeter (if defined)
+func (w *Writer) writeEOL() error {
 	if w.HasEOL != EOLNONE {
 		if w.HasEOL == EOLCR || w.HasEOL == EOLCRLF {
-			w.w.WriteByte(13)
+			if err := w.w.WriteByte(13); err != nil {
+				return err
+			}
 		}
 		if w.HasEOL == EOLLF || w.HasEOL == EOLCRLF {
-			w.w.WriteByte(10)
+			return w.w.WriteByte(10)
 		}
 	}
 	return nil
 }
 
+// errFieldChecksum - records written a field at a time can't have a checksum field
+var errFieldChecksum = fmt.Errorf("%w: the checksum can't be computed for records written with WriteField", ErrInvalidLayout)
+
+// WriteField writes the field at index of the current record, so that a record can be build up one field at a time.
+// Fields must be written in order, any fields that are skipped are written as blanks.
+// EndRecord must be called to complete the record. It can't be used with ChecksumFunc.
+func (w *Writer) WriteField(index int, value string) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if err := w.checkInit(); err != nil {
+		return err
+	}
+	if w.ChecksumFunc != nil {
+		return errFieldChecksum
+	}
+	if index < 0 || index >= len(w.FieldLengths) {
+		return ErrFieldCount
+	}
+	if index < w.nextfield {
+		return ErrFieldOrder
+	}
+	if !w.inrecord {
+		if err := w.writeBOM(); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(appendMargin(nil, w.Prefix, w.SkipStart)); err != nil {
+			return err
+		}
+		w.inrecord = true
+	}
+	for ; w.nextfield < index; w.nextfield++ {
+		if err := w.writeField(w.nextfield, ""); err != nil {
+			return err
+		}
+	}
+	if err := w.writeField(index, value); err != nil {
+		return err
+	}
+	w.nextfield = index + 1
+	return nil
+}
+
+// EndRecord completes the record started with WriteField by writing blanks for the remaining fields,
+// the trailing spaces (if SkipEnd is defined) and the line delimeter (if defined)
+func (w *Writer) EndRecord() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if err := w.checkInit(); err != nil {
+		return err
+	}
+	if w.ChecksumFunc != nil {
+		return errFieldChecksum
+	}
+	if !w.inrecord {
+		if err := w.writeBOM(); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(appendMargin(nil, w.Prefix, w.SkipStart)); err != nil {
+			return err
+		}
+		w.inrecord = true
+	}
+	for ; w.nextfield < len(w.FieldLengths); w.nextfield++ {
+		if err := w.writeField(w.nextfield, ""); err != nil {
+			return err
+		}
+	}
+	if _, err := w.w.Write(appendMargin(nil, w.Suffix, w.SkipEnd)); err != nil {
+		return err
+	}
+	if err := w.writeRecordEOL(); err != nil {
+		return err
+	}
+	w.line++
+	w.seq++
+	w.inrecord = false
+	w.nextfield = 0
+	return nil
+}
+
 // WriteAll will write every record in the slice to output
+// If ContinueOnError is set a record that fails is skipped and the rest are still written, the errors
+// are then returned combined (with errors.Join) and are available from Errors.
+// The output is flushed in both cases.
 func (w *Writer) WriteAll(recs [][]string) error {
-	for _, record := range recs {
+	w.errs = nil
+	for i, record := range recs {
 		err := w.Write(record)
 		if err != nil {
-			return err
+			if !w.ContinueOnError {
+				w.w.Flush()
+				return err
+			}
+			w.errs = append(w.errs, &RecordError{Index: i, Err: err})
 		}
 	}
-	w.w.Flush()
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	if len(w.errs) > 0 {
+		errs := make([]error, len(w.errs))
+		for i, e := range w.errs {
+			errs[i] = e
+		}
+		return errors.Join(errs...)
+	}
 	return nil
 }
 
-// Flush will flush the output stream
+// Errors returns the errors of the records that failed during the last WriteAll with ContinueOnError set
+func (w *Writer) Errors() []*RecordError {
+	return w.errs
+}
+
+// flushInterval is the number of records after which WriteFrom and WriteChan flush the output
+const flushInterval = 1000
+
+// WriteFrom will write every record produced by seq to output, flushing periodically and at the end.
+// It stops at the first error encountered.
+func (w *Writer) WriteFrom(seq iter.Seq[[]string]) error {
+	n := 0
+	for record := range seq {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+		n++
+		if n%flushInterval == 0 {
+			if err := w.w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return w.w.Flush()
+}
+
+// WriteChan will write every record received on ch to output until ch is closed.
+// It stops at the first error encountered without draining ch.
+func (w *Writer) WriteChan(ch <-chan []string) error {
+	return w.WriteFrom(func(yield func([]string) bool) {
+		for record := range ch {
+			if !yield(record) {
+				return
+			}
+		}
+	})
+}
+
+// Flush will flush the output stream, a partial group of records (see GroupSize) is ended with the line delimeter.
+// Use Error to check if it succeeded.
 func (w *Writer) Flush() {
+	if w.endGroup() != nil {
+		return // The error is kept by the buffer and returned by Error
+	}
 	w.w.Flush()
 }
 
+// Error reports any error that has occurred while writing to the output during a previous write or Flush
+func (w *Writer) Error() error {
+	_, err := w.w.Write(nil)
+	return err
+}
+
+// Close pads the output with BlockPad up to the next multiple of BlockSize (if defined)
+// and flushes the output stream. The padding follows the EOL of the last record.
+// Any error from writing to the output is returned. After Close any further writes return ErrWriterClosed,
+// calling Close again does nothing.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.seq = 0
+	if err := w.endGroup(); err != nil {
+		return err
+	}
+	if w.BlockSize > 0 {
+		written := w.cw.n + int64(w.w.Buffered())
+		if rem := written % int64(w.BlockSize); rem != 0 {
+			for i := rem; i < int64(w.BlockSize); i++ {
+				if err := w.w.WriteByte(w.BlockPad); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return w.w.Flush()
+}
+
+// Reset restarts the numbering of records used for SequenceColumn
+func (w *Writer) Reset() {
+	w.seq = 0
+}
+
+// countReader - keeps track of the number of bytes read from the input
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countWriter - keeps track of the number of bytes written to the output
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteFill writes a line of the full record width filled by repeating pattern (for example "-" for a
+// separator in a report), the last repetition is cut to fit the width exactly. An empty pattern fills with spaces.
+// The line is not a record so it isn't counted by SequenceColumn.
+func (w *Writer) WriteFill(pattern string) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if err := w.checkInit(); err != nil {
+		return err
+	}
+	if pattern == "" {
+		pattern = " "
+	}
+	line := strings.Repeat(pattern, w.width/len(pattern)+1)[:w.width]
+	if err := w.writeBOM(); err != nil {
+		return err
+	}
+	if err := w.endGroup(); err != nil {
+		return err
+	}
+	if _, err := w.w.WriteString(line); err != nil {
+		return err
+	}
+	if err := w.writeEOL(); err != nil {
+		return err
+	}
+	w.line++
+	return nil
+}
+
 // WriteComment send a comment character and the provided line to the output
+// The comment is cut to the record width and if PadComments is set it is padded with spaces to the record width
 func (w *Writer) WriteComment(line string) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
 	if w.Comment != 0 {
+		if err := w.checkInit(); err != nil {
+			return err
+		}
+		if err := w.writeBOM(); err != nil {
+			return err
+		}
+		if err := w.endGroup(); err != nil {
+			return err
+		}
 		_, err := w.w.WriteRune(w.Comment)
 		if err != nil {
 			return err
 		}
-		if len(line)+1 > w.width {
-			line = line[0 : w.width-1]
+		// The comment character can be more than one byte
+		avail := w.width - utf8.RuneLen(w.Comment)
+		if avail < 0 {
+			avail = 0
 		}
-		_, err = w.w.WriteString(line)
-		if len(line)+1 < w.width {
-			w.outputSpaces(w.width - len(line) - 1)
+		if len(line) > avail {
+			line = line[:avail]
 		}
+		_, err = w.w.WriteString(line)
 		if err != nil {
 			return err
 		}
-		// Output line delimeter if defined
-		if w.HasEOL != EOLNONE {
-			if w.HasEOL == EOLCR || w.HasEOL == EOLCRLF {
-				w.w.WriteByte(13)
-			}
-			if w.HasEOL == EOLLF || w.HasEOL == EOLCRLF {
-				w.w.WriteByte(10)
+		if w.PadComments {
+			if err = w.outputSpaces(avail - len(line)); err != nil {
+				return err
 			}
 		}
+		// Output line delimeter if defined
+		if err = w.writeEOL(); err != nil {
+			return err
+		}
+		w.line++
 	}
 	return nil
 }
+
+// FormatFields formats the fields to one line without having to create a Writer.
+// The fields are padded with pad according to align (all left aligned if nil) and
+// ErrFieldLengthError is returned if a field is too long.
+func FormatFields(flds []string, lengths, align []int, pad rune) (string, error) {
+	w := &Writer{FieldLengths: lengths, FieldAlign: align, PadChar: pad, SequenceColumn: -1}
+	if err := w.Init(); err != nil {
+		return "", err
+	}
+	return w.FormatRecord(flds)
+}
+
+// SplitFields splits a single line into fields of the given lengths without having to create a Reader.
+// The line must be exactly as long as the fields together else ErrIncorrectLineWidth is returned.
+// If trim is set the fields are trimmed.
+func SplitFields(line string, lengths []int, trim bool) ([]string, error) {
+	r := &Reader{FieldLengths: lengths, TrimFields: trim}
+	if err := r.Init(); err != nil {
+		return nil, err
+	}
+	if len(line) != r.width {
+		return nil, ErrIncorrectLineWidth
+	}
+	return r.splitRecord(line)
+}
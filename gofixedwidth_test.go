@@ -0,0 +1,992 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestReader - a Reader for input with LF delimited lines and the given field lengths
+func newTestReader(input string, lengths ...int) *Reader {
+	r := NewReader(strings.NewReader(input))
+	r.HasEOL = EOLLF
+	r.FieldLengths = lengths
+	return r
+}
+
+// readAll - Init the reader and read all the records
+func readAll(t *testing.T, r *Reader) ([][]string, error) {
+	t.Helper()
+	if err := r.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	return r.ReadAll()
+}
+
+func TestAllowTrailingBytes(t *testing.T) {
+	record := strings.Repeat("a", 60) + strings.Repeat("b", 40)
+	padded := record + strings.Repeat(" ", 20)
+	tests := []struct {
+		name  string
+		input string
+		allow bool
+		err   error
+	}{
+		{"padded to 120", padded + "\n" + padded + "\n", true, nil},
+		{"exactly 100", record + "\n", true, nil},
+		{"padded without AllowTrailingBytes", padded + "\n", false, ErrIncorrectLineWidth},
+		{"shorter than 100", record[:99] + "\n", true, ErrIncorrectLineWidth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(tt.input, 60, 40)
+			r.AllowTrailingBytes = tt.allow
+			recs, err := readAll(t, r)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			for _, rec := range recs {
+				if rec[0] != record[:60] || rec[1] != record[60:] {
+					t.Errorf("got %q, want the 100 byte record split at 60", rec)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		setup func(r *Reader)
+		err   error
+	}{
+		{"valid", "abcd\nefgh\n", func(r *Reader) {}, nil},
+		{"width", "abcd\nefg\n", func(r *Reader) {}, ErrIncorrectLineWidth},
+		{"MaxFieldLen", "abcd\nefgh\n", func(r *Reader) { r.MaxFieldLen = []int{1} }, ErrFieldTooLong},
+		{"checksum", "ab" + LRCHex([]byte("ab")) + "\ncdzz\n", func(r *Reader) {
+			r.ChecksumFunc = LRCHex
+			r.ChecksumColumn = 1
+		}, ErrChecksum},
+		{"rune boundary", "abcd\naéc\n", func(r *Reader) { r.CheckRuneBoundaries = true }, ErrRuneBoundary},
+		{"padding", "a bc\na\tcd\n", func(r *Reader) { r.VerifyPadding = true }, ErrInvalidPadding},
+		{"encoding", "\x12\x3cab\n\x12\x34ab\n", func(r *Reader) {
+			r.FieldEncoding = []Decoder{PackedDecoder(0)}
+		}, ErrInvalidPacked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(tt.input, 2, 2)
+			tt.setup(r)
+			if err := r.Init(); err != nil {
+				t.Fatalf("Init: %v", err)
+			}
+			err := r.Validate()
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestInterleavedComments(t *testing.T) {
+	input := "# head\nabcd\n# one\nefgh\n# two\n\n# three\nijkl\n# tail\n"
+	want := [][]string{{"ab", "cd"}, {"ef", "gh"}, {"ij", "kl"}}
+	newReader := func() *Reader {
+		r := newTestReader(input, 2, 2)
+		r.Comment = '#'
+		if err := r.Init(); err != nil {
+			t.Fatalf("Init: %v", err)
+		}
+		return r
+	}
+
+	r := newReader()
+	var recs [][]string
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		recs = append(recs, rec)
+	}
+	if !reflect.DeepEqual(recs, want) {
+		t.Errorf("Read: got %q, want %q", recs, want)
+	}
+
+	r = newReader()
+	recs, err := r.ReadRows(len(want))
+	if err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("ReadRows: got %q, %v, want %q", recs, err, want)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read after ReadRows: got error %v, want io.EOF", err)
+	}
+
+	// Read and ReadRows can be mixed
+	r = newReader()
+	first, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	rest, err := r.ReadRows(2)
+	if err != nil || !reflect.DeepEqual(append([][]string{first}, rest...), want) {
+		t.Errorf("Read then ReadRows: got %q %q, %v, want %q", first, rest, err, want)
+	}
+
+	recs, err = readAll(t, newTestReader(input, 2, 2))
+	if err == nil {
+		t.Errorf("without Comment the comments are records, got %q", recs)
+	}
+}
+
+func TestTabWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		tabwidth int
+		want     [][]string
+		err      error
+	}{
+		{"tab to the next stop", "ab\tcd  ef\n", 4, [][]string{{"ab  ", "cd  ", "ef"}}, nil},
+		{"tab at a stop", "abcd\tef\n", 4, [][]string{{"abcd", "    ", "ef"}}, nil},
+		{"several tabs", "\t\tef\n", 4, [][]string{{"    ", "    ", "ef"}}, nil},
+		{"no tabs", "abcdefghij\n", 4, [][]string{{"abcd", "efgh", "ij"}}, nil},
+		{"no expansion by default", "ab\tcd  ef\n", 0, nil, ErrIncorrectLineWidth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(tt.input, 4, 4, 2)
+			r.TabWidth = tt.tabwidth
+			recs, err := readAll(t, r)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(recs, tt.want) {
+				t.Errorf("got %q, want %q", recs, tt.want)
+			}
+		})
+	}
+}
+
+// initRead - Init the reader and read all the records, an error from Init is returned as well
+func initRead(r *Reader) error {
+	if err := r.Init(); err != nil {
+		return err
+	}
+	_, err := r.ReadAll()
+	return err
+}
+
+// newTestWriter - a Writer with LF delimited lines and the given field lengths
+func newTestWriter(sb *strings.Builder, lengths ...int) *Writer {
+	w := NewWriterEOL(sb, EOLLF)
+	w.FieldLengths = lengths
+	return w
+}
+
+func TestSentinelErrors(t *testing.T) {
+	var sb strings.Builder
+	tests := []struct {
+		sentinel error
+		run      func() error
+	}{
+		{ErrFieldCount, func() error {
+			return newTestWriter(&sb, 2, 2).Write([]string{"a"})
+		}},
+		{ErrNoFields, func() error {
+			return newTestReader("").Init()
+		}},
+		{ErrFieldLengthError, func() error {
+			return newTestReader("", 2, 0).Init()
+		}},
+		{ErrIncorrectLineWidth, func() error {
+			return initRead(newTestReader("abc\n", 2, 2))
+		}},
+		{ErrNotEnoughLines, func() error {
+			r := newTestReader("abcd\n", 2, 2)
+			r.Init()
+			_, err := r.ReadExactly(2)
+			return err
+		}},
+		{ErrTooManyLines, func() error {
+			r := newTestReader("abcd\nefgh\n", 2, 2)
+			r.Init()
+			_, err := r.ReadExactly(1)
+			return err
+		}},
+		{ErrNoFieldNames, func() error {
+			return newTestReader("abcd\n", 2, 2).ReadJSON(&sb)
+		}},
+		{ErrInvalidCharacter, func() error {
+			r := newTestReader("ab\x01d\n", 2, 2)
+			r.ASCIIOnly = true
+			return initRead(r)
+		}},
+		{ErrNegativeSkip, func() error {
+			r := newTestReader("", 2, 2)
+			r.SkipStart = -1
+			return r.Init()
+		}},
+		{ErrFieldOrder, func() error {
+			w := newTestWriter(&sb, 2, 2)
+			w.WriteField(1, "a")
+			return w.WriteField(0, "b")
+		}},
+		{ErrMissingCRLF, func() error {
+			r := newTestReader("abcd\rx", 2, 2)
+			r.HasEOL = EOLCRLF
+			return initRead(r)
+		}},
+		{ErrUnknownEOL, func() error {
+			r := newTestReader("abcd\n", 2, 2)
+			r.HasEOL = 9
+			return initRead(r)
+		}},
+		{ErrMissingEOL, func() error {
+			r := newTestReader("abcdx", 2, 2)
+			r.FixedWidthEOL = true
+			return initRead(r)
+		}},
+		{ErrUnknownRecordType, func() error {
+			r := newTestReader("Xbcd\n", 2, 2)
+			r.PrefixLen = 1
+			r.WidthFor = func(prefix string) int { return 0 }
+			return initRead(r)
+		}},
+		{ErrFieldTooLong, func() error {
+			r := newTestReader("abcd\n", 2, 2)
+			r.MaxFieldLen = []int{1, 0}
+			return initRead(r)
+		}},
+		{ErrWriterClosed, func() error {
+			w := newTestWriter(&sb, 2, 2)
+			w.Close()
+			return w.Write([]string{"a", "b"})
+		}},
+		{ErrLineTooLong, func() error {
+			r := newTestReader("abcdefgh\n", 2, 2)
+			r.MaxLineLen = 6
+			return initRead(r)
+		}},
+		{ErrChecksum, func() error {
+			r := newTestReader("abcd00\n", 2, 2, 2)
+			r.ChecksumFunc = LRCHex
+			r.ChecksumColumn = 2
+			return initRead(r)
+		}},
+		{ErrRuneBoundary, func() error {
+			r := newTestReader("aé\n", 2, 1)
+			r.CheckRuneBoundaries = true
+			return initRead(r)
+		}},
+		{ErrMissingField, func() error {
+			w := newTestWriter(&sb, 2, 2)
+			w.FieldNames = []string{"a", "b"}
+			return w.WriteMap(map[string]string{"a": "x"})
+		}},
+		{ErrInvalidPadding, func() error {
+			r := newTestReader("a\t  \n", 2, 2)
+			r.VerifyPadding = true
+			return initRead(r)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sentinel.Error(), func(t *testing.T) {
+			err := tt.run()
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("got error %v, want one that wraps %q", err, tt.sentinel)
+			}
+		})
+	}
+}
+
+var errWrite = errors.New("write failed")
+
+// failWriter - an output that can't be written to
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestWriteFieldErrors(t *testing.T) {
+	w := NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{2, 2}
+	w.SkipStart = 8192 // Larger than the buffer so the margin reaches the output straight away
+	if err := w.WriteField(0, "ab"); !errors.Is(err, errWrite) {
+		t.Errorf("WriteField: got error %v, want %v", err, errWrite)
+	}
+	w = NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{2, 2}
+	w.SkipEnd = 8192
+	if err := w.WriteField(0, "ab"); err != nil {
+		t.Fatalf("WriteField: %v", err)
+	}
+	if err := w.EndRecord(); !errors.Is(err, errWrite) {
+		t.Errorf("EndRecord: got error %v, want %v", err, errWrite)
+	}
+}
+
+func TestRuneBoundariesAtTheEnd(t *testing.T) {
+	// The raw last field is empty
+	r := newTestReader("ab\nabcdé\n", 2, 5)
+	r.CheckRuneBoundaries = true
+	r.LastFieldRaw = true
+	recs, err := readAll(t, r)
+	if want := [][]string{{"ab", ""}, {"ab", "cdé"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("LastFieldRaw: got %q, %v, want %q", recs, err, want)
+	}
+
+	// The whole line field is empty
+	r = newTestReader("12\n12é\n")
+	r.CheckRuneBoundaries = true
+	r.WholeLine = true
+	r.SkipStart = 2
+	recs, err = readAll(t, r)
+	if want := [][]string{{""}, {"é"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("WholeLine: got %q, %v, want %q", recs, err, want)
+	}
+
+	// A field that splits a character is still found
+	r = newTestReader("aéb\n", 2, 2)
+	r.CheckRuneBoundaries = true
+	if _, err := readAll(t, r); !errors.Is(err, ErrRuneBoundary) {
+		t.Errorf("got error %v, want ErrRuneBoundary", err)
+	}
+}
+
+func TestFixedWidthEOL(t *testing.T) {
+	tests := []struct {
+		eol   int
+		input string
+		err   error
+	}{
+		{EOLLF, "ab\ncd\n", nil},
+		{EOLCR, "ab\rcd", nil},
+		{EOLCRLF, "ab\r\ncd\r\n", nil},
+		{EOLCRLF, "ab\ncd\n", ErrMissingEOL},
+		{EOLLF, "abxcd\n", ErrMissingEOL},
+	}
+	for _, tt := range tests {
+		r := newTestReader(tt.input, 1, 1)
+		r.HasEOL = tt.eol
+		r.FixedWidthEOL = true
+		recs, err := readAll(t, r)
+		if !errors.Is(err, tt.err) || (tt.err == nil && !reflect.DeepEqual(recs, [][]string{{"a", "b"}, {"c", "d"}})) {
+			t.Errorf("%q: got %q, %v, want error %v", tt.input, recs, err, tt.err)
+		}
+	}
+}
+
+func TestWriteAlignedErrors(t *testing.T) {
+	w := NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{8192}
+	if err := w.WriteAligned([]string{"a"}, []int{ALIGNRIGHT}); !errors.Is(err, errWrite) {
+		t.Errorf("got error %v, want %v", err, errWrite)
+	}
+	// The line delimeter is the byte that doesn't fit in the buffer anymore
+	w = NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{4096}
+	if err := w.WriteAligned([]string{"a"}, nil); !errors.Is(err, errWrite) {
+		t.Errorf("got error %v for the line delimeter, want %v", err, errWrite)
+	}
+}
+
+func TestCRLines(t *testing.T) {
+	want := [][]string{{"ab", "cd"}, {"ef", "gh"}}
+	tests := []struct {
+		name  string
+		input string
+		eol   int
+		want  [][]string
+	}{
+		{"CR terminated", "abcd\refgh\r", EOLCR, want},
+		{"CR without a final CR", "abcd\refgh", EOLCR, want},
+		{"CR with empty lines", "\rabcd\r\refgh\r", EOLCR, want},
+		{"CR empty input", "", EOLCR, [][]string{}},
+		{"CRLF terminated", "abcd\r\nefgh\r\n", EOLCRLF, want},
+		{"CRLF without a final CRLF", "abcd\r\nefgh", EOLCRLF, want},
+		{"CRLF with a lone final CR", "abcd\r\nefgh\r", EOLCRLF, want},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(tt.input, 2, 2)
+			r.HasEOL = tt.eol
+			recs, err := readAll(t, r)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if !reflect.DeepEqual(recs, tt.want) {
+				t.Errorf("got %q, want %q", recs, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineDelimeterInRecord(t *testing.T) {
+	r := newTestReader("ab\rd\n", 2, 2)
+	_, err := readAll(t, r)
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrIncorrectLineWidth) || pe.Column != 2 {
+		t.Errorf("got error %v, want ErrIncorrectLineWidth in column 2", err)
+	}
+}
+
+func TestLastFieldRaw(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  [][]string
+		err   error
+	}{
+		{"raw last field is not trimmed", " ab 12  free text  \n", [][]string{{"ab", "12", "  free text  "}}, nil},
+		{"shorter last field", " ab 12x\n", [][]string{{"ab", "12", "x"}}, nil},
+		{"empty last field", " ab 12\n", [][]string{{"ab", "12", ""}}, nil},
+		{"other fields must fit", " ab 1\n", nil, ErrIncorrectLineWidth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(tt.input, 3, 3, 4)
+			r.TrimFields = true
+			r.LastFieldRaw = true
+			recs, err := readAll(t, r)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(recs, tt.want) {
+				t.Errorf("got %q, want %q", recs, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlignRightBlank(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		align int
+		want  string
+	}{
+		{"empty is blank", "", ALIGNRIGHTBLANK, "     |"},
+		{"populated is right aligned", "42", ALIGNRIGHTBLANK, "00042|"},
+		{"full width", "12345", ALIGNRIGHTBLANK, "12345|"},
+		{"empty right aligned is padding", "", ALIGNRIGHT, "00000|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			w := newTestWriter(&sb, 5, 1)
+			w.FieldAlign = []int{tt.align, ALIGNLEFT}
+			w.PadChar = '0'
+			line, err := w.FormatRecord([]string{tt.value, "|"})
+			if err != nil {
+				t.Fatalf("FormatRecord: %v", err)
+			}
+			if line != tt.want {
+				t.Errorf("got %q, want %q", line, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequenceOverflow(t *testing.T) {
+	var sb strings.Builder
+	w := newTestWriter(&sb, 1, 2)
+	w.SequenceColumn = 0
+	w.TrimFields = true
+	for i := 1; i <= 9; i++ {
+		if err := w.Write([]string{"", "ab"}); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+	}
+	err := w.Write([]string{"", "ab"})
+	var perr *ParseError
+	if !errors.Is(err, ErrFieldLengthError) || !errors.As(err, &perr) || perr.Column != 0 || perr.Line != 10 {
+		t.Errorf("record 10: got error %v, want ErrFieldLengthError in column 0 of line 10", err)
+	}
+	w.TrimOverflow = []bool{true, true}
+	if err := w.Write([]string{"", "ab"}); !errors.Is(err, ErrFieldLengthError) {
+		t.Errorf("with TrimOverflow: got error %v, want ErrFieldLengthError", err)
+	}
+	w.Flush()
+	if lines := strings.Count(sb.String(), "\n"); lines != 9 {
+		t.Errorf("got %d records written, want 9", lines)
+	}
+}
+
+func TestWidthFor(t *testing.T) {
+	widthFor := func(prefix string) int {
+		switch prefix {
+		case "A":
+			return 4
+		case "B":
+			return 7
+		}
+		return 0
+	}
+	input := "Babcdef\nAxyz\n"
+	want := [][]string{{"B", "abc", "def"}, {"A", "xyz", ""}}
+	r := newTestReader(input, 1, 3, 3)
+	r.PrefixLen = 1
+	r.WidthFor = widthFor
+	recs, err := readAll(t, r)
+	if err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("ReadAll: got %q, %v, want %q", recs, err, want)
+	}
+	r = newTestReader(input, 1, 3, 3)
+	r.PrefixLen = 1
+	r.WidthFor = widthFor
+	r.Init()
+	for i, rec := range want {
+		flds, err := r.ReadBytes()
+		if err != nil {
+			t.Fatalf("ReadBytes record %d: %v", i, err)
+		}
+		got := make([]string, len(flds))
+		for j, fld := range flds {
+			got[j] = string(fld)
+		}
+		if !reflect.DeepEqual(got, rec) {
+			t.Errorf("ReadBytes record %d: got %q, want %q", i, got, rec)
+		}
+	}
+	// A record that ends inside a field is an error
+	r = newTestReader("Babcdef\nAxyz\n", 1, 3, 3)
+	r.PrefixLen = 1
+	r.WidthFor = func(prefix string) int { return 6 }
+	r.AllowTrailingBytes = true
+	r.Init()
+	if _, err = r.ReadBytes(); !errors.Is(err, ErrIncorrectLineWidth) {
+		t.Errorf("ReadBytes: got error %v, want ErrIncorrectLineWidth", err)
+	}
+	r = newTestReader("Qabcdef\n", 1, 3, 3)
+	r.PrefixLen = 1
+	r.WidthFor = widthFor
+	if _, err = readAll(t, r); !errors.Is(err, ErrUnknownRecordType) {
+		t.Errorf("unknown flag: got error %v, want ErrUnknownRecordType", err)
+	}
+}
+
+func TestReinit(t *testing.T) {
+	var sb strings.Builder
+	w := newTestWriter(&sb, 2)
+	w.FieldAlign = []int{ALIGNRIGHT}
+	if err := w.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	w.FieldLengths = []int{2, 3, 1}
+	if err := w.Init(); err != nil {
+		t.Fatalf("Init after changing FieldLengths: %v", err)
+	}
+	if err := w.Write([]string{"a", "b", "c"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Flush()
+	if want := " ab  c\n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+	r := newTestReader("abcdef\n", 2)
+	r.Init()
+	r.FieldLengths = []int{1, 2, 3}
+	recs, err := readAll(t, r)
+	if want := [][]string{{"a", "bc", "def"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("got %q, %v, want %q", recs, err, want)
+	}
+	if len(r.FieldAlign) != 3 {
+		t.Errorf("got %d alignments, want 3", len(r.FieldAlign))
+	}
+}
+
+func TestSkipCountsComments(t *testing.T) {
+	input := "# comment\nH1H1\n# comment\nH2H2\nabcd\n"
+	tests := []struct {
+		name   string
+		counts bool
+		want   [][]string
+	}{
+		{"comments are not counted", false, [][]string{{"ab", "cd"}}},
+		{"comments are counted", true, [][]string{{"H2", "H2"}, {"ab", "cd"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(input, 2, 2)
+			r.Comment = '#'
+			r.SkipLines = 2
+			r.SkipCountsComments = tt.counts
+			recs, err := readAll(t, r)
+			if err != nil || !reflect.DeepEqual(recs, tt.want) {
+				t.Errorf("got %q, %v, want %q", recs, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadExactly(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+		err   error
+	}{
+		{"under", "abcd\nefgh\n", 2, ErrNotEnoughLines},
+		{"exact", "abcd\nefgh\nijkl\n", 3, nil},
+		{"over", "abcd\nefgh\nijkl\nmnop\n", 3, ErrTooManyLines},
+		{"exact with trailing comment", "abcd\nefgh\nijkl\n# end\n", 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(tt.input, 2, 2)
+			r.Comment = '#'
+			r.Init()
+			recs, err := r.ReadExactly(3)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if len(recs) != tt.want {
+				t.Errorf("got %d records, want %d", len(recs), tt.want)
+			}
+		})
+	}
+}
+
+func TestPadComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		pad     bool
+		comment string
+		want    string
+	}{
+		{"padded", true, "hi", "#hi   \n"},
+		{"unpadded", false, "hi", "#hi\n"},
+		{"padded full width", true, "hello", "#hello\n"},
+		{"padded cut", true, "hello world", "#hello\n"},
+		{"unpadded cut", false, "hello world", "#hello\n"},
+		{"padded empty", true, "", "#     \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			w := newTestWriter(&sb, 3, 3)
+			w.Comment = '#'
+			w.PadComments = tt.pad
+			if err := w.WriteComment(tt.comment); err != nil {
+				t.Fatalf("WriteComment: %v", err)
+			}
+			w.Flush()
+			if sb.String() != tt.want {
+				t.Errorf("got %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+	// A comment character of more than one byte takes up its bytes of the width
+	var sb strings.Builder
+	w := newTestWriter(&sb, 3, 3)
+	w.Comment = '§'
+	w.WriteComment("ab")
+	w.Flush()
+	if want := "§ab  \n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestStripLeadingZeros(t *testing.T) {
+	tests := []struct {
+		field, want string
+	}{
+		{"000042", "42"},
+		{"42", "42"},
+		{"0", "0"},
+		{"0000", "0"},
+		{"-00042", "-42"},
+		{"+00042", "+42"},
+		{"-0000", "-0"},
+		{"000.50", "0.50"},
+		{"-00.5", "-0.5"},
+		{"00100", "100"},
+		{"", ""},
+		{"-", "-"},
+	}
+	for _, tt := range tests {
+		if got := stripLeadingZeros(tt.field); got != tt.want {
+			t.Errorf("stripLeadingZeros(%q): got %q, want %q", tt.field, got, tt.want)
+		}
+	}
+	r := newTestReader("0000420000-0070000\n", 6, 4, 4, 4)
+	r.StripLeadingZeros = []bool{true, true, true}
+	recs, err := readAll(t, r)
+	want := [][]string{{"42", "0", "-7", "0000"}}
+	if err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("got %q, %v, want %q", recs, err, want)
+	}
+}
+
+func TestCloseGroupError(t *testing.T) {
+	// The record fills the buffer so the line delimeter that ends the group has to reach the output
+	w := NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{4096}
+	w.GroupSize = 2
+	if err := w.Write([]string{"a"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, errWrite) {
+		t.Errorf("got error %v, want %v", err, errWrite)
+	}
+}
+
+func TestOverlappingRanges(t *testing.T) {
+	// A date, its year, month and day, a code and the whole line
+	input := "20240315AB\n19991231CD\n"
+	ranges := [][2]int{{0, 8}, {0, 4}, {4, 6}, {6, 8}, {8, 10}, {0, 10}}
+	want := [][]string{
+		{"20240315", "2024", "03", "15", "AB", "20240315AB"},
+		{"19991231", "1999", "12", "31", "CD", "19991231CD"},
+	}
+	r := newTestReader(input)
+	r.FieldRanges = ranges
+	recs, err := readAll(t, r)
+	if err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("Read: got %q, %v, want %q", recs, err, want)
+	}
+	if want := []int{8, 4, 2, 2, 2, 10}; !reflect.DeepEqual(r.FieldLengths, want) {
+		t.Errorf("got FieldLengths %v, want %v", r.FieldLengths, want)
+	}
+	if lay := r.Layout(); len(lay) != len(ranges) || lay[2].Offset != 4 || lay[5].Length != 10 {
+		t.Errorf("got layout %+v", lay)
+	}
+
+	r = newTestReader(input)
+	r.FieldRanges = ranges
+	if err := r.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	for _, rec := range want {
+		flds, err := r.ReadBytes()
+		if err != nil {
+			t.Fatalf("ReadBytes: %v", err)
+		}
+		for i, fld := range flds {
+			if string(fld) != rec[i] {
+				t.Errorf("ReadBytes field %d: got %q, want %q", i, fld, rec[i])
+			}
+		}
+	}
+
+	// The ranges don't have to be in order and the width is the furthest end
+	r = newTestReader("abcdef\n")
+	r.FieldRanges = [][2]int{{2, 6}, {0, 3}}
+	recs, err = readAll(t, r)
+	if want := [][]string{{"cdef", "abc"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("unordered: got %q, %v, want %q", recs, err, want)
+	}
+
+	for _, rng := range [][2]int{{-1, 2}, {2, 2}, {3, 1}} {
+		r = newTestReader("abcdef\n")
+		r.FieldRanges = [][2]int{{0, 6}, rng}
+		if err := r.Init(); !errors.Is(err, ErrFieldLengthError) {
+			t.Errorf("range %v: got error %v, want ErrFieldLengthError", rng, err)
+		}
+	}
+}
+
+func TestWrapOverflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(w *Writer)
+		rec   []string
+		want  string
+	}{
+		{"fits", func(w *Writer) {}, []string{"1", "short", "x"}, "1 shortx \n"},
+		{"one field wraps", func(w *Writer) {}, []string{"1", "a long text", "x"},
+			"1 a lonx \n  g tex  \n  t      \n"},
+		{"two fields wrap", func(w *Writer) {}, []string{"123", "abcdefg", "xyz"},
+			"12abcdexy\n3 fg   z \n"},
+		{"right aligned", func(w *Writer) { w.FieldAlign = []int{ALIGNLEFT, ALIGNRIGHT, ALIGNLEFT} },
+			[]string{"1", "abcdefg", ""}, "1 abcde  \n     fg  \n"},
+		{"sequence on every line", func(w *Writer) { w.SequenceColumn = 0 }, []string{"", "abcdefg", "x"},
+			"01abcdex \n02fg     \n"},
+		{"control characters are not counted", func(w *Writer) { w.StripControls = true },
+			[]string{"1", "ab\tcdefg\r", "x"}, "1 abcdex \n  fg     \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			w := newTestWriter(&sb, 2, 5, 2)
+			w.WrapOverflow = true
+			tt.setup(w)
+			if err := w.Write(tt.rec); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			w.Flush()
+			if sb.String() != tt.want {
+				t.Errorf("got %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+
+	// A field where nothing fits isn't wrapped so it can't loop, TrimFields decides
+	var sb strings.Builder
+	w := newTestWriter(&sb, 1)
+	w.WrapOverflow = true
+	w.DisplayWidths = true
+	w.EastAsianWidths = true
+	if err := w.Write([]string{"漢字"}); !errors.Is(err, ErrFieldLengthError) {
+		t.Errorf("wide characters: got error %v, want ErrFieldLengthError", err)
+	}
+}
+
+func TestCommentAfterSkip(t *testing.T) {
+	// The first two bytes are a record type, the comment marker is at byte 2
+	input := "01#skipped\n01abcd\n#2efgh\n"
+	tests := []struct {
+		name      string
+		afterSkip bool
+		offset    int
+		want      [][]string
+	}{
+		{"after the skip", true, 0, [][]string{{"ab", "cd"}, {"ef", "gh"}}},
+		{"offset from the start of the line", false, 2, [][]string{{"ab", "cd"}, {"ef", "gh"}}},
+		// Without the skip the first line is a record and the last one a comment
+		{"start of the line", false, 0, [][]string{{"#s", "ki"}, {"ab", "cd"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(input, 2, 2)
+			r.SkipStart = 2
+			r.Comment = '#'
+			r.CommentAfterSkip = tt.afterSkip
+			r.CommentOffset = tt.offset
+			r.AllowTrailingBytes = true
+			recs, err := readAll(t, r)
+			if err != nil || !reflect.DeepEqual(recs, tt.want) {
+				t.Errorf("got %q, %v, want %q", recs, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictTrim(t *testing.T) {
+	input := " ab    cd  1.5 \n"
+	align := []int{ALIGNLEFT, ALIGNRIGHT, ALIGNDECIMAL}
+	tests := []struct {
+		name   string
+		strict bool
+		align  []int
+		want   []string
+	}{
+		{"both sides", false, align, []string{"ab", "cd", "1.5"}},
+		{"padding side", true, align, []string{" ab", "cd ", "1.5"}},
+		{"right aligned blank", true, []int{ALIGNRIGHTBLANK, ALIGNRIGHTBLANK, ALIGNLEFT}, []string{"ab  ", "cd ", " 1.5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(input, 5, 5, 5)
+			r.TrimFields = true
+			r.StrictTrim = tt.strict
+			r.FieldAlign = tt.align
+			recs, err := readAll(t, r)
+			if err != nil || len(recs) != 1 || !reflect.DeepEqual(recs[0], tt.want) {
+				t.Fatalf("got %q, %v, want %q", recs, err, tt.want)
+			}
+			// ReadBytes trims the same way
+			r = newTestReader(input, 5, 5, 5)
+			r.TrimFields = true
+			r.StrictTrim = tt.strict
+			r.FieldAlign = tt.align
+			r.Init()
+			flds, err := r.ReadBytes()
+			if err != nil {
+				t.Fatalf("ReadBytes: %v", err)
+			}
+			for i, fld := range flds {
+				if string(fld) != tt.want[i] {
+					t.Errorf("ReadBytes field %d: got %q, want %q", i, fld, tt.want[i])
+				}
+			}
+		})
+	}
+	// With FieldFiller only the filler on the padding side is removed
+	r := newTestReader("00120**ab*\n", 5, 5)
+	r.FieldFiller = '0'
+	r.StrictTrim = true
+	r.FieldAlign = []int{ALIGNRIGHT, ALIGNLEFT}
+	recs, err := readAll(t, r)
+	if want := [][]string{{"120", "**ab*"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("FieldFiller: got %q, %v, want %q", recs, err, want)
+	}
+}
+
+func TestWriterPathErrors(t *testing.T) {
+	// The comment doesn't fit in the buffer so it reaches the output halfway
+	w := NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{6000}
+	w.Comment = '#'
+	if err := w.WriteComment(strings.Repeat("c", 5000)); !errors.Is(err, errWrite) {
+		t.Errorf("WriteComment: got error %v, want %v", err, errWrite)
+	}
+
+	// Every line of a wrapped record is checked, the line delimeter of the first one fails
+	w = NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{4096}
+	w.WrapOverflow = true
+	if err := w.Write([]string{strings.Repeat("w", 5000)}); !errors.Is(err, errWrite) {
+		t.Errorf("wrapped Write: got error %v, want %v", err, errWrite)
+	}
+
+	// Flush ends the partial group, the error is reported by Error
+	w = NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{4096}
+	w.GroupSize = 2
+	if err := w.Write([]string{"a"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Error(); err != nil {
+		t.Errorf("Error before Flush: %v", err)
+	}
+	w.Flush()
+	if err := w.Error(); !errors.Is(err, errWrite) {
+		t.Errorf("Error after Flush: got %v, want %v", err, errWrite)
+	}
+
+	// Transform returns the error of the final Flush
+	r := newTestReader("ab\n", 2)
+	r.Init()
+	w = NewWriterEOL(failWriter{}, EOLLF)
+	w.FieldLengths = []int{2}
+	if err := Transform(r, w, nil); !errors.Is(err, errWrite) {
+		t.Errorf("Transform: got error %v, want %v", err, errWrite)
+	}
+}
+
+func TestPassthroughExactWidth(t *testing.T) {
+	var sb strings.Builder
+	w := newTestWriter(&sb, 3, 3, 6)
+	w.PassthroughExactWidth = true
+	w.FieldAlign = []int{ALIGNRIGHT, ALIGNLEFT, ALIGNDECIMAL}
+	w.FieldDecimals = []int{0, 0, 2}
+	w.FieldEncoding = []Encoder{nil, PackedEncoder(3, 0)}
+	// "1.5000" is as wide as its field but has too many decimals
+	if err := w.Write([]string{"abc", "123", "1.5000"}); !errors.Is(err, ErrFieldLengthError) {
+		t.Errorf("got error %v, want ErrFieldLengthError", err)
+	}
+	// "abc" is kept as is and "123" is as wide as its field but still packed
+	if err := w.Write([]string{"abc", "123", "1.5"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Write([]string{"ab", "-45", "7"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Flush()
+	want := "abc\x00\x12\x3c  1.5 \n ab\x00\x04\x5d  7   \n"
+	if sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
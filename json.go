@@ -0,0 +1,181 @@
+package gofixedwidth
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrNotJSONArray = errors.New("expected a JSON array")
+
+// checkNames - make sure there is a name for every field
+func checkNames(names []string, lengths []int) error {
+	if len(names) == 0 || len(names) != len(lengths) {
+		return ErrNoFieldNames
+	}
+	return nil
+}
+
+// jsonValue - encode a single field value, if a type is defined in JSONTypes for the field
+// the value is written unquoted (after checking that it is valid for the type)
+func (r *Reader) jsonValue(name, value string) ([]byte, error) {
+	switch r.JSONTypes[name] {
+	case "number":
+		if strings.TrimSpace(value) == "" {
+			return []byte("null"), nil
+		}
+		// Reformat the number so that things like leading zeros still produce valid JSON
+		if i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			return []byte(strconv.FormatInt(i, 10)), nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) { // JSON has no NaN or infinity
+			return nil, fmt.Errorf("field %q: %q is not a number", name, value)
+		}
+		return []byte(strconv.FormatFloat(f, 'f', -1, 64)), nil
+	case "bool":
+		if strings.TrimSpace(value) == "" {
+			return []byte("null"), nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %q is not a bool", name, value)
+		}
+		return []byte(strconv.FormatBool(b)), nil
+	}
+	return json.Marshal(value)
+}
+
+// jsonRecord - encode a record as a JSON object with the keys in the order of the fields
+func (r *Reader) jsonRecord(record []string) ([]byte, error) {
+	buf := []byte{'{'}
+	for i, name := range r.FieldNames {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := r.jsonValue(name, record[i])
+		if err != nil {
+			return nil, r.error(err)
+		}
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		buf = append(buf, val...)
+	}
+	return append(buf, '}'), nil
+}
+
+// ReadJSON reads all the remaining records and streams them to w as a JSON array of objects
+// keyed by FieldNames. If NDJSON is set every object is written on its own line instead.
+// Values are written as strings unless JSONTypes defines the field as a number or bool.
+func (r *Reader) ReadJSON(w io.Writer) error {
+	if err := checkNames(r.FieldNames, r.FieldLengths); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if !r.NDJSON {
+		bw.WriteByte('[')
+	}
+	first := true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+		buf, err := r.jsonRecord(record)
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+		if !r.NDJSON && !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		if _, err = bw.Write(buf); err != nil {
+			return err
+		}
+		if r.NDJSON {
+			bw.WriteByte('\n')
+		}
+	}
+	if !r.NDJSON {
+		bw.WriteString("]\n")
+	}
+	return bw.Flush()
+}
+
+// jsonString - convert a decoded JSON value to the string to be written in the field
+func jsonString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	tmp, _ := json.Marshal(val)
+	return string(tmp)
+}
+
+// writeJSONObject - write a decoded JSON object as a record, missing keys are written as empty fields
+func (w *Writer) writeJSONObject(obj map[string]interface{}) error {
+	flds := make([]string, len(w.FieldNames))
+	for i, name := range w.FieldNames {
+		flds[i] = jsonString(obj[name])
+	}
+	return w.Write(flds)
+}
+
+// WriteJSON reads a JSON array of objects (or one object per line if NDJSON is set) from r
+// and writes every object as a record, the values are looked up by FieldNames
+func (w *Writer) WriteJSON(r io.Reader) error {
+	if err := checkNames(w.FieldNames, w.FieldLengths); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if !w.NDJSON {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return ErrNotJSONArray
+		}
+	}
+	for {
+		if !w.NDJSON && !dec.More() {
+			break
+		}
+		var obj map[string]interface{}
+		err := dec.Decode(&obj)
+		if err == io.EOF && w.NDJSON {
+			break
+		}
+		if err != nil {
+			w.Flush()
+			return err
+		}
+		if err = w.writeJSONObject(obj); err != nil {
+			w.Flush()
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,48 @@
+package gofixedwidth
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONNumbers(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"0042", `{"n":42}` + "\n", true},
+		{" 1.5", `{"n":1.5}` + "\n", true},
+		{"    ", `{"n":null}` + "\n", true},
+		{" NaN", "", false},
+		{"+Inf", "", false},
+		{"-inf", "", false},
+		{"12ab", "", false},
+	}
+	for _, tt := range tests {
+		r := NewReader(strings.NewReader(tt.value + "\n"))
+		r.HasEOL = EOLLF
+		r.FieldLengths = []int{4}
+		r.FieldNames = []string{"n"}
+		r.JSONTypes = map[string]string{"n": "number"}
+		r.NDJSON = true
+		r.Init()
+		var sb strings.Builder
+		err := r.ReadJSON(&sb)
+		if (err == nil) != tt.ok || (tt.ok && sb.String() != tt.want) {
+			t.Errorf("%q: got %q, %v, want %q", tt.value, sb.String(), err, tt.want)
+		}
+	}
+}
+
+func TestWriteJSONError(t *testing.T) {
+	pr, pw := io.Pipe()
+	pr.Close() // Nothing can be written to pw
+	w := NewWriterEOL(pw, EOLLF)
+	w.FieldLengths = []int{2}
+	w.FieldNames = []string{"a"}
+	if err := w.WriteJSON(strings.NewReader(`[{"a":"x"}]`)); err != io.ErrClosedPipe {
+		t.Errorf("got error %v, want %v", err, io.ErrClosedPipe)
+	}
+}
@@ -0,0 +1,317 @@
+package gofixedwidth
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidLayout = errors.New("invalid layout definition")
+
+// LayoutField describes a single column in a layout spec
+//   Name - the name of the field
+//   Offset - the zero based byte offset of the field in the line
+//   Length - the number of bytes of the field
+//   Align - "left", "right", "rightblank" or "decimal" (defaults to left)
+//   Type - type of the field used by ReadTyped: "string", "int", "float", "bool" or "time" (others are read as strings)
+type LayoutField struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Align  string `json:"align,omitempty"`
+	Type   string `json:"type,omitempty"`
+}
+
+// Layout is a parsed layout spec that can be applied to a Reader or Writer
+type Layout struct {
+	Fields []LayoutField
+}
+
+// ParseLayout reads a layout definition from r.
+// The definition can either be a JSON array of objects with the keys name, offset, length, align and type
+// or a text form with one field per line:
+//   name offset length [align [type]]
+// In the text form blank lines and lines starting with '#' are ignored.
+// The fields must be contiguous and may not overlap, the offset of the first field is used as SkipStart.
+func ParseLayout(r io.Reader) (*Layout, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var lay *Layout
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		lay, err = parseJSONLayout(trimmed)
+	} else {
+		lay, err = parseTextLayout(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = lay.validate(); err != nil {
+		return nil, err
+	}
+	return lay, nil
+}
+
+// parseJSONLayout - decode the JSON form of a layout
+func parseJSONLayout(data []byte) (*Layout, error) {
+	lay := &Layout{}
+	if err := json.Unmarshal(data, &lay.Fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLayout, err)
+	}
+	return lay, nil
+}
+
+// parseTextLayout - decode the text form of a layout
+func parseTextLayout(data []byte) (*Layout, error) {
+	lay := &Layout{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for sc.Scan() {
+		line++
+		tmp := strings.TrimSpace(sc.Text())
+		if tmp == "" || tmp[0] == '#' {
+			continue
+		}
+		parts := strings.Fields(tmp)
+		if len(parts) < 3 || len(parts) > 5 {
+			return nil, &ParseError{Line: line, Err: ErrInvalidLayout}
+		}
+		fld := LayoutField{Name: parts[0]}
+		var err error
+		if fld.Offset, err = strconv.Atoi(parts[1]); err != nil {
+			return nil, &ParseError{Line: line, Column: 2, Err: ErrInvalidLayout}
+		}
+		if fld.Length, err = strconv.Atoi(parts[2]); err != nil {
+			return nil, &ParseError{Line: line, Column: 3, Err: ErrInvalidLayout}
+		}
+		if len(parts) > 3 {
+			fld.Align = parts[3]
+		}
+		if len(parts) > 4 {
+			fld.Type = parts[4]
+		}
+		lay.Fields = append(lay.Fields, fld)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return lay, nil
+}
+
+// validate - check that the fields are contiguous, don't overlap and have known alignments
+// The fields are sorted on their offsets
+func (l *Layout) validate() error {
+	if len(l.Fields) == 0 {
+		return ErrNoFields
+	}
+	sort.SliceStable(l.Fields, func(i, j int) bool { return l.Fields[i].Offset < l.Fields[j].Offset })
+	for i, fld := range l.Fields {
+		if fld.Length <= 0 || fld.Offset < 0 {
+			return fmt.Errorf("%w: field %q: %v", ErrInvalidLayout, fld.Name, ErrFieldLengthError)
+		}
+		if _, err := parseAlign(fld.Align); err != nil {
+			return fmt.Errorf("%w: field %q: %v", ErrInvalidLayout, fld.Name, err)
+		}
+		if i > 0 {
+			prev := l.Fields[i-1]
+			if fld.Offset < prev.Offset+prev.Length {
+				return fmt.Errorf("%w: field %q overlaps field %q", ErrInvalidLayout, fld.Name, prev.Name)
+			}
+			if fld.Offset > prev.Offset+prev.Length {
+				return fmt.Errorf("%w: gap between field %q and field %q", ErrInvalidLayout, prev.Name, fld.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// parseAlign - convert the textual alignment to one of the ALIGN constants
+func parseAlign(align string) (int, error) {
+	switch strings.ToLower(align) {
+	case "", "left":
+		return ALIGNLEFT, nil
+	case "right":
+		return ALIGNRIGHT, nil
+	case "rightblank":
+		return ALIGNRIGHTBLANK, nil
+	case "decimal":
+		return ALIGNDECIMAL, nil
+	}
+	return 0, fmt.Errorf("unknown alignment %q", align)
+}
+
+// Lengths returns the field lengths of the layout
+func (l *Layout) Lengths() []int {
+	result := make([]int, len(l.Fields))
+	for i, fld := range l.Fields {
+		result[i] = fld.Length
+	}
+	return result
+}
+
+// Aligns returns the alignment of each field of the layout
+func (l *Layout) Aligns() []int {
+	result := make([]int, len(l.Fields))
+	for i, fld := range l.Fields {
+		result[i], _ = parseAlign(fld.Align)
+	}
+	return result
+}
+
+// Names returns the names of the fields of the layout
+func (l *Layout) Names() []string {
+	result := make([]string, len(l.Fields))
+	for i, fld := range l.Fields {
+		result[i] = fld.Name
+	}
+	return result
+}
+
+// Types returns the type of each field of the layout
+func (l *Layout) Types() []FieldType {
+	result := make([]FieldType, len(l.Fields))
+	for i, fld := range l.Fields {
+		result[i] = parseType(fld.Type)
+	}
+	return result
+}
+
+// skipStart - the offset of the first field
+func (l *Layout) skipStart() int {
+	if len(l.Fields) == 0 {
+		return 0
+	}
+	return l.Fields[0].Offset
+}
+
+// ApplyReader sets the field definitions of the reader from the layout and initializes it
+func (l *Layout) ApplyReader(r *Reader) error {
+	r.SkipStart = l.skipStart()
+	r.FieldLengths = l.Lengths()
+	r.FieldAlign = l.Aligns()
+	r.FieldNames = l.Names()
+	r.FieldTypes = l.Types()
+	return r.Init()
+}
+
+// ApplyWriter sets the field definitions of the writer from the layout and initializes it
+func (l *Layout) ApplyWriter(w *Writer) error {
+	w.SkipStart = l.skipStart()
+	w.FieldLengths = l.Lengths()
+	w.FieldAlign = l.Aligns()
+	w.FieldNames = l.Names()
+	return w.Init()
+}
+
+// Layout returns the effective layout of the reader after Init: the absolute offset, length,
+// alignment, name and type of every field. It is nil if Init wasn't run successfully.
+func (r *Reader) Layout() []LayoutField {
+	result := make([]LayoutField, 0, len(r.offsets))
+	for i, rng := range r.offsets {
+		fld := LayoutField{Offset: rng[0], Length: rng[1] - rng[0], Align: alignName(ALIGNLEFT), Type: typeName(TYPESTRING)}
+		if i < len(r.FieldNames) {
+			fld.Name = r.FieldNames[i]
+		}
+		if i < len(r.FieldAlign) {
+			fld.Align = alignName(r.FieldAlign[i])
+		}
+		if i < len(r.FieldTypes) {
+			fld.Type = typeName(r.FieldTypes[i])
+		}
+		result = append(result, fld)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Layout returns the effective layout of the writer: the absolute offset, length, alignment
+// and name of every field (the fields follow each other after SkipStart)
+func (w *Writer) Layout() []LayoutField {
+	if len(w.FieldLengths) == 0 {
+		return nil
+	}
+	result := make([]LayoutField, 0, len(w.FieldLengths))
+	offset := w.SkipStart
+	for i, length := range w.FieldLengths {
+		fld := LayoutField{Offset: offset, Length: length, Align: alignName(ALIGNLEFT)}
+		if i < len(w.FieldNames) {
+			fld.Name = w.FieldNames[i]
+		}
+		if i < len(w.FieldAlign) {
+			fld.Align = alignName(w.FieldAlign[i])
+		}
+		result = append(result, fld)
+		offset += length
+	}
+	return result
+}
+
+// alignName - the textual form of an ALIGN constant as used in a layout spec
+func alignName(align int) string {
+	switch align {
+	case ALIGNRIGHT:
+		return "right"
+	case ALIGNRIGHTBLANK:
+		return "rightblank"
+	case ALIGNDECIMAL:
+		return "decimal"
+	}
+	return "left"
+}
+
+// typeName - the textual form of a TYPE constant as used in a layout spec
+func typeName(typ FieldType) string {
+	switch typ {
+	case TYPEINT:
+		return "int"
+	case TYPEFLOAT:
+		return "float"
+	case TYPEBOOL:
+		return "bool"
+	case TYPETIME:
+		return "time"
+	}
+	return "string"
+}
+
+// DeriveLengthsFromRuler sets FieldLengths, SkipStart and SkipEnd from a ruler line like "  ----- ---- -----  "
+// and initializes the reader. Every run of characters other than spaces starts a field that extends up to the
+// start of the next run, so the spaces between the runs belong to the field in front of them.
+// The spaces in front of the first run are SkipStart and the spaces after the last run are SkipEnd.
+func (r *Reader) DeriveLengthsFromRuler(line string) error {
+	var starts []int
+	end := 0
+	for i := 0; i < len(line); i++ {
+		if line[i] == ' ' {
+			continue
+		}
+		if i == 0 || line[i-1] == ' ' {
+			starts = append(starts, i)
+		}
+		end = i + 1
+	}
+	if len(starts) == 0 {
+		return ErrNoFields
+	}
+	r.FieldRanges = nil
+	r.SkipStart = starts[0]
+	r.SkipEnd = len(line) - end
+	r.FieldLengths = make([]int, len(starts))
+	for i, start := range starts {
+		if i < len(starts)-1 {
+			r.FieldLengths[i] = starts[i+1] - start
+		} else {
+			r.FieldLengths[i] = end - start
+		}
+	}
+	return r.Init()
+}
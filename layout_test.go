@@ -0,0 +1,120 @@
+package gofixedwidth
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	want := &Layout{Fields: []LayoutField{
+		{Name: "code", Offset: 2, Length: 3, Align: "right", Type: "int"},
+		{Name: "name", Offset: 5, Length: 10},
+		{Name: "amount", Offset: 15, Length: 8, Align: "decimal", Type: "float"},
+	}}
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"text", "# code first\ncode 2 3 right int\n\n  name 5 10\namount 15 8 decimal float\n"},
+		{"text out of order", "amount 15 8 decimal float\ncode 2 3 right int\nname 5 10\n"},
+		{"json", `[{"name":"code","offset":2,"length":3,"align":"right","type":"int"},
+			{"name":"name","offset":5,"length":10},
+			{"name":"amount","offset":15,"length":8,"align":"decimal","type":"float"}]`},
+		{"json with leading space", "\n  " + `[{"name":"name","offset":5,"length":10},{"name":"amount","offset":15,"length":8,"align":"decimal","type":"float"},
+			{"name":"code","offset":2,"length":3,"align":"right","type":"int"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lay, err := ParseLayout(strings.NewReader(tt.spec))
+			if err != nil || !reflect.DeepEqual(lay, want) {
+				t.Errorf("got %+v, %v, want %+v", lay, err, want)
+			}
+		})
+	}
+}
+
+func TestParseLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		err  error
+		line int // Line of the ParseError (0 if it isn't one)
+	}{
+		{"empty", "", ErrNoFields, 0},
+		{"only comments", "# nothing\n\n", ErrNoFields, 0},
+		{"empty json", "[]", ErrNoFields, 0},
+		{"invalid json", `[{"name":"a","offset":"x"}]`, ErrInvalidLayout, 0},
+		{"too few parts", "a 0 2\nb 2\n", ErrInvalidLayout, 2},
+		{"too many parts", "a 0 2 left string extra\n", ErrInvalidLayout, 1},
+		{"bad offset", "a x 2\n", ErrInvalidLayout, 1},
+		{"bad length", "a 0 two\n", ErrInvalidLayout, 1},
+		{"zero length", "a 0 0\n", ErrInvalidLayout, 0},
+		{"negative offset", "a -1 2\n", ErrInvalidLayout, 0},
+		{"unknown alignment", "a 0 2 middle\n", ErrInvalidLayout, 0},
+		{"overlap", "a 0 3\nb 2 2\n", ErrInvalidLayout, 0},
+		{"gap", "a 0 2\nb 3 2\n", ErrInvalidLayout, 0},
+		{"json overlap", `[{"name":"a","offset":0,"length":3},{"name":"b","offset":2,"length":2}]`, ErrInvalidLayout, 0},
+		{"line too long", strings.Repeat("a", bufio.MaxScanTokenSize+1), bufio.ErrTooLong, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLayout(strings.NewReader(tt.spec))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			var perr *ParseError
+			if tt.line > 0 && (!errors.As(err, &perr) || perr.Line != tt.line) {
+				t.Errorf("got error %v, want a ParseError on line %d", err, tt.line)
+			}
+		})
+	}
+	if _, err := ParseLayout(failReader{}); !errors.Is(err, errRead) {
+		t.Errorf("got error %v, want %v", err, errRead)
+	}
+}
+
+var errRead = errors.New("read failed")
+
+// failReader - an input that can't be read
+type failReader struct{}
+
+func (failReader) Read(p []byte) (int, error) {
+	return 0, errRead
+}
+
+func TestLayoutApply(t *testing.T) {
+	lay, err := ParseLayout(strings.NewReader("code 2 3 right int\nname 5 4 rightblank\n"))
+	if err != nil {
+		t.Fatalf("ParseLayout: %v", err)
+	}
+	r := NewReader(strings.NewReader("xx042 bob\n"))
+	r.HasEOL = EOLLF
+	r.TrimFields = true
+	if err := lay.ApplyReader(r); err != nil {
+		t.Fatalf("ApplyReader: %v", err)
+	}
+	if r.SkipStart != 2 || !reflect.DeepEqual(r.FieldNames, []string{"code", "name"}) ||
+		!reflect.DeepEqual(r.FieldTypes, []FieldType{TYPEINT, TYPESTRING}) {
+		t.Errorf("got SkipStart %d, names %q, types %v", r.SkipStart, r.FieldNames, r.FieldTypes)
+	}
+	recs, err := r.ReadAll()
+	if want := [][]string{{"042", "bob"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("got %q, %v, want %q", recs, err, want)
+	}
+
+	var sb strings.Builder
+	w := NewWriterEOL(&sb, EOLLF)
+	if err := lay.ApplyWriter(w); err != nil {
+		t.Fatalf("ApplyWriter: %v", err)
+	}
+	if err := w.Write([]string{"42", ""}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Flush()
+	if want := "   42    \n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+}
@@ -0,0 +1,23 @@
+package gofixedwidth
+
+import "fmt"
+
+// WriteMap writes a record from a map keyed by FieldNames. A name that isn't in the map gets its value from
+// FieldDefaults, if it doesn't have a default either ErrMissingField is returned and nothing is written.
+// Keys of the map that are not field names are ignored.
+func (w *Writer) WriteMap(m map[string]string) error {
+	if err := checkNames(w.FieldNames, w.FieldLengths); err != nil {
+		return err
+	}
+	flds := make([]string, len(w.FieldNames))
+	for i, name := range w.FieldNames {
+		val, ok := m[name]
+		if !ok {
+			if val, ok = w.FieldDefaults[name]; !ok {
+				return &ParseError{Line: w.line + 1, Column: i, Err: fmt.Errorf("%w: %q has no default", ErrMissingField, name)}
+			}
+		}
+		flds[i] = val
+	}
+	return w.Write(flds)
+}
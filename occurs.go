@@ -0,0 +1,79 @@
+package gofixedwidth
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AddRepeatingGroup inserts a group of fields that repeats timesOccur times (like a COBOL OCCURS clause)
+// into FieldLengths before the field at index start (len(FieldLengths) to add it at the end).
+// subAlign is the alignment of every field in the group (all left aligned if nil).
+// The fields of every occurrence are returned flattened in the record. The fields of the group are named
+// "<occurrence>_<subfield>" (1 based) in FieldNames and get their zero value in the other per field options
+// that are defined. A per field option that is defined must already cover the fields before start, otherwise
+// ErrFieldCount is returned. Init is called to check the new layout (including ExpectedWidth).
+func (r *Reader) AddRepeatingGroup(start, timesOccur int, subLengths, subAlign []int) error {
+	if len(r.FieldRanges) > 0 {
+		return fmt.Errorf("%w: repeating groups can't be used with FieldRanges", ErrInvalidLayout)
+	}
+	if start < 0 || start > len(r.FieldLengths) || timesOccur < 1 || len(subLengths) == 0 {
+		return fmt.Errorf("%w: invalid repeating group at field %d", ErrInvalidLayout, start)
+	}
+	if subAlign != nil && len(subAlign) != len(subLengths) {
+		return fmt.Errorf("%w: %d alignments for %d group fields", ErrFieldCount, len(subAlign), len(subLengths))
+	}
+	// Check everything before the layout is changed
+	for _, opt := range []struct {
+		name   string
+		length int
+	}{
+		{"FieldAlign", len(r.FieldAlign)},
+		{"FieldNames", len(r.FieldNames)},
+		{"MaxFieldLen", len(r.MaxFieldLen)},
+		{"FieldTypes", len(r.FieldTypes)},
+		{"FieldDateLayouts", len(r.FieldDateLayouts)},
+		{"FieldTransform", len(r.FieldTransform)},
+		{"FieldEncoding", len(r.FieldEncoding)},
+		{"StripLeadingZeros", len(r.StripLeadingZeros)},
+	} {
+		if opt.length > 0 && opt.length < start {
+			return fmt.Errorf("%w: %s has %d fields, the group starts at field %d", ErrFieldCount, opt.name, opt.length, start)
+		}
+	}
+	size := timesOccur * len(subLengths)
+	lengths := make([]int, 0, size)
+	align := make([]int, 0, size)
+	names := make([]string, 0, size)
+	for k := 0; k < timesOccur; k++ {
+		for j, length := range subLengths {
+			lengths = append(lengths, length)
+			if subAlign != nil {
+				align = append(align, subAlign[j])
+			} else {
+				align = append(align, ALIGNLEFT)
+			}
+			names = append(names, fmt.Sprintf("%d_%d", k+1, j+1))
+		}
+	}
+	if len(r.FieldAlign) == 0 && subAlign != nil {
+		r.FieldAlign = make([]int, len(r.FieldLengths))
+	}
+	r.FieldLengths = slices.Insert(r.FieldLengths, start, lengths...)
+	r.FieldAlign = insertGroup(r.FieldAlign, start, align)
+	r.FieldNames = insertGroup(r.FieldNames, start, names)
+	r.MaxFieldLen = insertGroup(r.MaxFieldLen, start, make([]int, size))
+	r.FieldTypes = insertGroup(r.FieldTypes, start, make([]FieldType, size))
+	r.FieldDateLayouts = insertGroup(r.FieldDateLayouts, start, make([]string, size))
+	r.FieldTransform = insertGroup(r.FieldTransform, start, make([]func(string) string, size))
+	r.FieldEncoding = insertGroup(r.FieldEncoding, start, make([]Decoder, size))
+	r.StripLeadingZeros = insertGroup(r.StripLeadingZeros, start, make([]bool, size))
+	return r.Init()
+}
+
+// insertGroup - insert the values of a group into a per field option, an option that isn't defined stays undefined
+func insertGroup[T any](opt []T, start int, group []T) []T {
+	if len(opt) == 0 {
+		return opt
+	}
+	return slices.Insert(opt, start, group...)
+}
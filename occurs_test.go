@@ -0,0 +1,72 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAddRepeatingGroup(t *testing.T) {
+	r := newTestReader("ID  12a 3bEND\n", 4, 3)
+	r.TrimFields = true
+	r.FieldNames = []string{"id", "end"}
+	r.FieldAlign = []int{ALIGNLEFT, ALIGNLEFT}
+	if err := r.AddRepeatingGroup(1, 2, []int{2, 1}, []int{ALIGNRIGHT, ALIGNLEFT}); err != nil {
+		t.Fatalf("AddRepeatingGroup: %v", err)
+	}
+	wantNames := []string{"id", "1_1", "1_2", "2_1", "2_2", "end"}
+	if !reflect.DeepEqual(r.FieldNames, wantNames) {
+		t.Errorf("got names %q, want %q", r.FieldNames, wantNames)
+	}
+	wantAlign := []int{ALIGNLEFT, ALIGNRIGHT, ALIGNLEFT, ALIGNRIGHT, ALIGNLEFT, ALIGNLEFT}
+	if !reflect.DeepEqual(r.FieldAlign, wantAlign) {
+		t.Errorf("got alignments %v, want %v", r.FieldAlign, wantAlign)
+	}
+	recs, err := r.ReadAll()
+	want := [][]string{{"ID", "12", "a", "3", "b", "END"}}
+	if err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("got %q, %v, want %q", recs, err, want)
+	}
+}
+
+func TestAddRepeatingGroupAlignWithoutFieldAlign(t *testing.T) {
+	r := newTestReader("ab 1 2\n", 2, 2)
+	if err := r.AddRepeatingGroup(2, 1, []int{2}, []int{ALIGNRIGHT}); err != nil {
+		t.Fatalf("AddRepeatingGroup: %v", err)
+	}
+	if want := []int{ALIGNLEFT, ALIGNLEFT, ALIGNRIGHT}; !reflect.DeepEqual(r.FieldAlign, want) {
+		t.Errorf("got alignments %v, want %v", r.FieldAlign, want)
+	}
+	if r.FieldNames != nil || r.FieldEncoding != nil {
+		t.Errorf("undefined options were defined: %q %v", r.FieldNames, r.FieldEncoding)
+	}
+}
+
+func TestAddRepeatingGroupErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(r *Reader)
+		subAlign []int
+		err      error
+	}{
+		{"short FieldNames", func(r *Reader) { r.FieldNames = []string{"a"} }, nil, ErrFieldCount},
+		{"short FieldAlign", func(r *Reader) { r.FieldAlign = []int{ALIGNLEFT} }, nil, ErrFieldCount},
+		{"short FieldEncoding", func(r *Reader) { r.FieldEncoding = []Decoder{nil} }, nil, ErrFieldCount},
+		{"short StripLeadingZeros", func(r *Reader) { r.StripLeadingZeros = []bool{true} }, nil, ErrFieldCount},
+		{"wrong number of alignments", func(r *Reader) {}, []int{ALIGNLEFT, ALIGNRIGHT}, ErrFieldCount},
+		{"FieldRanges", func(r *Reader) { r.FieldRanges = [][2]int{{0, 2}} }, nil, ErrInvalidLayout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader("", 2, 2, 2)
+			tt.setup(r)
+			lengths := append([]int(nil), r.FieldLengths...)
+			if err := r.AddRepeatingGroup(2, 2, []int{1}, tt.subAlign); !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(r.FieldLengths, lengths) {
+				t.Errorf("FieldLengths changed to %v after an error", r.FieldLengths)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPacked = errors.New("invalid packed decimal")
+
+// DecodePacked converts a packed decimal (COBOL COMP-3) value to a decimal string with scale digits after the
+// decimal point. Every byte holds two digits except the last, whose low nibble is the sign
+// (0xD or 0xB is negative, 0xA, 0xC, 0xE or 0xF is positive).
+func DecodePacked(b []byte, scale int) (string, error) {
+	if len(b) == 0 {
+		return "", fmt.Errorf("%w: no bytes", ErrInvalidPacked)
+	}
+	if scale < 0 {
+		return "", fmt.Errorf("%w: negative scale %d", ErrInvalidPacked, scale)
+	}
+	digits := make([]byte, 0, len(b)*2-1)
+	for i, c := range b {
+		hi, lo := c>>4, c&0x0f
+		if hi > 9 || (i < len(b)-1 && lo > 9) {
+			return "", fmt.Errorf("%w: byte 0x%02x", ErrInvalidPacked, c)
+		}
+		digits = append(digits, '0'+hi)
+		if i < len(b)-1 {
+			digits = append(digits, '0'+lo)
+		}
+	}
+	var negative bool
+	switch b[len(b)-1] & 0x0f {
+	case 0x0b, 0x0d:
+		negative = true
+	case 0x0a, 0x0c, 0x0e, 0x0f:
+	default:
+		return "", fmt.Errorf("%w: sign 0x%x", ErrInvalidPacked, b[len(b)-1]&0x0f)
+	}
+	// Make sure there is at least one digit in front of the decimal point
+	for len(digits) <= scale {
+		digits = append([]byte{'0'}, digits...)
+	}
+	intpart := strings.TrimLeft(string(digits[:len(digits)-scale]), "0")
+	if intpart == "" {
+		intpart = "0"
+	}
+	result := intpart
+	if scale > 0 {
+		result += "." + string(digits[len(digits)-scale:])
+	}
+	if negative && strings.Trim(string(digits), "0") != "" {
+		result = "-" + result
+	}
+	return result, nil
+}
+
+// EncodePacked converts a decimal string (for example "-123.45") to a packed decimal (COBOL COMP-3) value
+// of exactly size bytes with scale digits after the decimal point. A value with more fractional digits
+// than scale or with more digits than fit into size bytes is an error. An empty value is encoded as zero.
+func EncodePacked(value string, size, scale int) ([]byte, error) {
+	if size <= 0 {
+		return nil, ErrFieldLengthError
+	}
+	if scale < 0 {
+		return nil, fmt.Errorf("%w: negative scale %d", ErrInvalidPacked, scale)
+	}
+	value = strings.TrimSpace(value)
+	var sign byte = 0x0c
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = 0x0d
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	intpart, fracpart, _ := strings.Cut(value, ".")
+	if len(fracpart) > scale {
+		return nil, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidPacked, value, scale)
+	}
+	digits := strings.TrimLeft(intpart+fracpart+strings.Repeat("0", scale-len(fracpart)), "0")
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("%w: %q is not a number", ErrInvalidPacked, value)
+		}
+	}
+	if len(digits) > size*2-1 {
+		return nil, fmt.Errorf("%w: %q doesn't fit into %d bytes", ErrInvalidPacked, value, size)
+	}
+	digits = strings.Repeat("0", size*2-1-len(digits)) + digits
+	result := make([]byte, size)
+	for i := 0; i < size; i++ {
+		hi := digits[i*2] - '0'
+		lo := sign
+		if i < size-1 {
+			lo = digits[i*2+1] - '0'
+		}
+		result[i] = hi<<4 | lo
+	}
+	return result, nil
+}
+
+// PackedDecoder returns a Decoder for FieldEncoding that converts a packed decimal field to a decimal string
+// with scale digits after the decimal point (see DecodePacked)
+func PackedDecoder(scale int) Decoder {
+	return packedCodec{scale: scale}
+}
+
+// PackedEncoder returns an Encoder for the FieldEncoding of a Writer that converts a decimal string to a
+// packed decimal of size bytes (see EncodePacked), size should be the length of the field
+func PackedEncoder(size, scale int) Encoder {
+	return packedCodec{size: size, scale: scale}
+}
+
+// packedCodec - decodes packed decimals (if size isn't defined) or encodes them to size bytes
+type packedCodec struct {
+	size  int
+	scale int
+}
+
+// Bytes decodes or encodes b
+func (p packedCodec) Bytes(b []byte) ([]byte, error) {
+	if p.size == 0 {
+		s, err := DecodePacked(b, p.scale)
+		return []byte(s), err
+	}
+	return EncodePacked(string(b), p.size, p.scale)
+}
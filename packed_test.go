@@ -0,0 +1,118 @@
+package gofixedwidth
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// Known COMP-3 values, as produced by COBOL for PIC S9(n)V9(scale) COMP-3 fields
+var packedTests = []struct {
+	packed []byte
+	scale  int
+	value  string
+}{
+	{[]byte{0x12, 0x3c}, 0, "123"},
+	{[]byte{0x12, 0x3d}, 0, "-123"},
+	{[]byte{0x00, 0x0c}, 0, "0"},
+	{[]byte{0x0c}, 0, "0"},
+	{[]byte{0x9c}, 0, "9"},
+	{[]byte{0x01, 0x23, 0x45, 0x6c}, 2, "1234.56"},
+	{[]byte{0x00, 0x12, 0x34, 0x5d}, 2, "-123.45"},
+	{[]byte{0x00, 0x00, 0x5c}, 2, "0.05"},
+	{[]byte{0x00, 0x00, 0x5d}, 3, "-0.005"},
+	{[]byte{0x99, 0x99, 0x9c}, 1, "9999.9"},
+}
+
+func TestDecodePacked(t *testing.T) {
+	for _, tt := range packedTests {
+		got, err := DecodePacked(tt.packed, tt.scale)
+		if err != nil || got != tt.value {
+			t.Errorf("DecodePacked(% x, %d): got %q, %v, want %q", tt.packed, tt.scale, got, err, tt.value)
+		}
+	}
+	// The other sign nibbles
+	for _, sign := range []struct {
+		packed []byte
+		value  string
+	}{{[]byte{0x1a}, "1"}, {[]byte{0x1b}, "-1"}, {[]byte{0x1e}, "1"}, {[]byte{0x1f}, "1"}, {[]byte{0x0d}, "0"}} {
+		if got, err := DecodePacked(sign.packed, 0); err != nil || got != sign.value {
+			t.Errorf("DecodePacked(% x): got %q, %v, want %q", sign.packed, got, err, sign.value)
+		}
+	}
+}
+
+func TestEncodePacked(t *testing.T) {
+	for _, tt := range packedTests {
+		got, err := EncodePacked(tt.value, len(tt.packed), tt.scale)
+		if err != nil || !bytes.Equal(got, tt.packed) {
+			t.Errorf("EncodePacked(%q, %d, %d): got % x, %v, want % x", tt.value, len(tt.packed), tt.scale, got, err, tt.packed)
+		}
+	}
+	for _, tt := range []struct {
+		value string
+		want  []byte
+	}{{"+42", []byte{0x04, 0x2c}}, {"", []byte{0x00, 0x0c}}, {" 7.1 ", []byte{0x07, 0x1c}}} {
+		scale := 0
+		if tt.value == " 7.1 " {
+			scale = 1
+		}
+		if got, err := EncodePacked(tt.value, 2, scale); err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("EncodePacked(%q): got % x, %v, want % x", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestPackedErrors(t *testing.T) {
+	decode := []struct {
+		packed []byte
+		scale  int
+	}{
+		{nil, 0},
+		{[]byte{0x12, 0x3c}, -1},
+		{[]byte{0x1a, 0x3c}, 0}, // A nibble above 9
+		{[]byte{0x12, 0x34}, 0}, // No sign
+	}
+	for _, tt := range decode {
+		if _, err := DecodePacked(tt.packed, tt.scale); !errors.Is(err, ErrInvalidPacked) {
+			t.Errorf("DecodePacked(% x, %d): got error %v, want ErrInvalidPacked", tt.packed, tt.scale, err)
+		}
+	}
+	encode := []struct {
+		value       string
+		size, scale int
+	}{
+		{"12", 2, -1},
+		{"1.234", 2, 2}, // Too many decimals
+		{"12345", 2, 0}, // Doesn't fit
+		{"12a", 2, 0},   // Not a number
+		{"1.2.3", 3, 2}, // Not a number
+	}
+	for _, tt := range encode {
+		if _, err := EncodePacked(tt.value, tt.size, tt.scale); !errors.Is(err, ErrInvalidPacked) {
+			t.Errorf("EncodePacked(%q, %d, %d): got error %v, want ErrInvalidPacked", tt.value, tt.size, tt.scale, err)
+		}
+	}
+}
+
+func TestPackedFields(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriterEOL(&buf, EOLLF)
+	w.FieldLengths = []int{2, 3}
+	w.FieldEncoding = []Encoder{nil, PackedEncoder(3, 2)}
+	if err := w.Write([]string{"ab", "-123.45"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Flush()
+	if want := []byte("ab\x12\x34\x5d\n"); !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("got % x, want % x", buf.Bytes(), want)
+	}
+	r := NewReader(&buf)
+	r.HasEOL = EOLLF
+	r.FieldLengths = []int{2, 3}
+	r.FieldEncoding = []Decoder{nil, PackedDecoder(2)}
+	recs, err := readAll(t, r)
+	if err != nil || len(recs) != 1 || recs[0][1] != "-123.45" {
+		t.Errorf("got %q, %v, want the value -123.45", recs, err)
+	}
+}
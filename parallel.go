@@ -0,0 +1,111 @@
+package gofixedwidth
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// ReadAllFunc reads all the records sequentially and calls fn for every record using a pool of workers goroutines.
+// fn is called concurrently and the calls for different records can happen (and finish) in any order, so fn must
+// be safe to use from more than one goroutine. The input order is NOT kept for whatever fn produces, use Read or
+// Stream when the records must be handled in order. Only the error is ordered: when an error occurs (reading or
+// from fn) no new records are handed out, the workers that are busy are allowed to finish and then the error of
+// the earliest record (in input order) that failed is returned.
+func (r *Reader) ReadAllFunc(workers int, fn func([]string) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	type job struct {
+		index  int
+		record []string
+	}
+	var (
+		mu       sync.Mutex
+		firstErr error
+		errIndex int
+		once     sync.Once
+		wg       sync.WaitGroup
+	)
+	jobs := make(chan job, workers)
+	stop := make(chan struct{})
+	fail := func(index int, err error) {
+		mu.Lock()
+		if firstErr == nil || index < errIndex {
+			firstErr = err
+			errIndex = index
+		}
+		mu.Unlock()
+		once.Do(func() { close(stop) })
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if err := fn(j.record); err != nil {
+					fail(j.index, err)
+				}
+			}
+		}()
+	}
+	for index := 0; ; index++ {
+		if cancelled(stop) {
+			break
+		}
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(index, err)
+			break
+		}
+		select {
+		case jobs <- job{index, record}:
+		case <-stop:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+// Stream reads the records in a goroutine and sends them on the records channel, at most StreamBuffer records are
+// read ahead of the receiver so a slow receiver holds up the reading. The records channel is closed at the end of
+// the input, after an error or when ctx is cancelled. The error that stopped it (the error of the context when
+// cancelled) is then sent on the error channel, which is closed after it and has no value at the end of the input.
+// The Reader must not be used by anything else until the records channel is closed.
+func (r *Reader) Stream(ctx context.Context) (<-chan []string, <-chan error) {
+	buffer := r.StreamBuffer
+	if buffer < 0 {
+		buffer = 0
+	}
+	records := make(chan []string, buffer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(records)
+		for {
+			if err := ctx.Err(); err != nil {
+				errs <- err
+				return
+			}
+			record, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case records <- record:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return records, errs
+}
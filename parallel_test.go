@@ -0,0 +1,124 @@
+package gofixedwidth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// numberedInput - n records of 4 digits with their index
+func numberedInput(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%04d\n", i)
+	}
+	return sb.String()
+}
+
+func TestReadAllFunc(t *testing.T) {
+	r := newTestReader(numberedInput(100), 4)
+	r.Init()
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	err := r.ReadAllFunc(4, func(rec []string) error {
+		mu.Lock()
+		seen[rec[0]] = true
+		mu.Unlock()
+		return nil
+	})
+	if err != nil || len(seen) != 100 {
+		t.Errorf("got %d records, %v, want 100", len(seen), err)
+	}
+}
+
+func TestReadAllFuncErrorOrder(t *testing.T) {
+	// Record 3 fails after record 7 but is earlier in the input
+	r := newTestReader(numberedInput(20), 4)
+	r.Init()
+	err := r.ReadAllFunc(8, func(rec []string) error {
+		switch rec[0] {
+		case "0003":
+			time.Sleep(20 * time.Millisecond)
+			return errors.New("record 3")
+		case "0007":
+			return errors.New("record 7")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "record 3" {
+		t.Errorf("got error %v, want the error of record 3", err)
+	}
+
+	// A read error is ordered with the errors of fn
+	r = newTestReader("0000\n0001\nbad\n0003\n", 4)
+	r.Init()
+	err = r.ReadAllFunc(2, func(rec []string) error { return nil })
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 3 {
+		t.Errorf("got error %v, want a ParseError on line 3", err)
+	}
+}
+
+func TestReadAllFuncStops(t *testing.T) {
+	r := newTestReader(numberedInput(1000), 4)
+	r.Init()
+	var calls atomic.Int32
+	err := r.ReadAllFunc(1, func(rec []string) error {
+		calls.Add(1)
+		return errors.New("stop")
+	})
+	// The record that failed, the one waiting for the worker and at most one more that was handed out
+	if err == nil || calls.Load() > 3 {
+		t.Errorf("got %d calls, %v, want an error after at most 3 calls", calls.Load(), err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := newTestReader(numberedInput(50), 4)
+	r.StreamBuffer = 4
+	r.Init()
+	records, errs := r.Stream(context.Background())
+	n := 0
+	for rec := range records {
+		if want := fmt.Sprintf("%04d", n); rec[0] != want {
+			t.Errorf("got %q, want %q", rec[0], want)
+		}
+		n++
+	}
+	if err := <-errs; err != nil || n != 50 {
+		t.Errorf("got %d records, %v, want 50", n, err)
+	}
+
+	r = newTestReader("0000\nbad\n", 4)
+	r.Init()
+	records, errs = r.Stream(context.Background())
+	for range records {
+	}
+	if err := <-errs; !errors.Is(err, ErrIncorrectLineWidth) {
+		t.Errorf("got error %v, want ErrIncorrectLineWidth", err)
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	r := newTestReader(numberedInput(1000), 4)
+	r.Init()
+	ctx, cancel := context.WithCancel(context.Background())
+	records, errs := r.Stream(ctx)
+	<-records
+	cancel()
+	n := 1
+	for range records {
+		n++
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) || n >= 1000 {
+		t.Errorf("got %d records, %v, want context.Canceled before the end", n, err)
+	}
+	if _, ok := <-errs; ok {
+		t.Errorf("the error channel isn't closed")
+	}
+}
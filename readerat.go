@@ -0,0 +1,82 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrRecordOutOfRange = errors.New("record number out of range")
+
+// NewReaderAt returns a Reader that can also read records directly by their number with ReadRecordAt.
+// recordWidth is the number of bytes each record takes up in the input including any line delimeter.
+// The Reader can still be used to read the input from the start with Read.
+func NewReaderAt(r io.ReaderAt, recordWidth int) *Reader {
+	tmp := NewReader(io.NewSectionReader(r, 0, 1<<63-1))
+	tmp.ra = r
+	tmp.recordwidth = recordWidth
+	return tmp
+}
+
+// ReadRecordAt reads the record with number n (starting at 0) at offset n*recordWidth of the input.
+// If the size of the input can be determined ErrRecordOutOfRange is returned when the record is past the end.
+// Only the record width is read, the bytes after it (like the line delimeter) are not checked so HasEOL,
+// FixedWidthEOL, RecordSep and SplitFunc don't apply. Records with a variable width (WidthFor, LengthField,
+// LastFieldRaw or WholeLine) can't be read by number and return ErrInvalidLayout.
+func (r *Reader) ReadRecordAt(n int) ([]string, error) {
+	if r.ra == nil || r.recordwidth < r.width {
+		return nil, ErrIncorrectLineWidth
+	}
+	if r.WidthFor != nil || r.HasLengthField || r.LastFieldRaw || r.wholeline {
+		return nil, fmt.Errorf("%w: records with a variable width can't be read by number", ErrInvalidLayout)
+	}
+	if n < 0 {
+		return nil, &ParseError{Line: n + 1, Err: ErrRecordOutOfRange}
+	}
+	offset := int64(n) * int64(r.recordwidth)
+	if size, ok := readerAtSize(r.ra); ok && offset+int64(r.width) > size {
+		return nil, &ParseError{Line: n + 1, Err: ErrRecordOutOfRange}
+	}
+	buf := make([]byte, r.width)
+	cnt, err := r.ra.ReadAt(buf, offset)
+	if cnt < len(buf) {
+		if err == nil || err == io.EOF {
+			err = ErrRecordOutOfRange
+		}
+		return nil, &ParseError{Line: n + 1, Err: err}
+	}
+	tmp, err := r.checkRecord(string(buf))
+	if err != nil {
+		return nil, recordAtError(n, err)
+	}
+	r.lastline = tmp
+	record, err := r.splitRecord(tmp)
+	if err != nil {
+		return nil, recordAtError(n, err)
+	}
+	return record, nil
+}
+
+// recordAtError - the error of record n as a ParseError, a ParseError keeps its column but gets the line of the record
+func recordAtError(n int, err error) error {
+	if pe, ok := err.(*ParseError); ok {
+		return &ParseError{Line: n + 1, Column: pe.Column, Err: pe.Err}
+	}
+	return &ParseError{Line: n + 1, Err: err}
+}
+
+// readerAtSize - determine the size of the input if it is possible
+func readerAtSize(ra io.ReaderAt) (int64, bool) {
+	switch v := ra.(type) {
+	case interface{ Size() int64 }:
+		return v.Size(), true
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil {
+			return 0, false
+		}
+		return info.Size(), true
+	}
+	return 0, false
+}
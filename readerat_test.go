@@ -0,0 +1,41 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRecordAt(t *testing.T) {
+	input := "ab12\n" + "cd34\n" + "ef5x\n"
+	r := NewReaderAt(strings.NewReader(input), 5)
+	r.HasEOL = EOLLF
+	r.FieldLengths = []int{2, 2}
+	r.MaxFieldLen = []int{0, 0}
+	if err := r.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	rec, err := r.ReadRecordAt(1)
+	if err != nil || !reflect.DeepEqual(rec, []string{"cd", "34"}) {
+		t.Errorf("record 1: got %q, %v", rec, err)
+	}
+	_, err = r.ReadRecordAt(3)
+	if !errors.Is(err, ErrRecordOutOfRange) {
+		t.Errorf("record 3: got error %v, want ErrRecordOutOfRange", err)
+	}
+	// An error from splitting the record keeps its column and gets the line of the record
+	r.MaxFieldLen = []int{0, 1}
+	_, err = r.ReadRecordAt(2)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 3 || pe.Column != 1 || !errors.Is(err, ErrFieldTooLong) {
+		t.Fatalf("record 2: got error %v, want ErrFieldTooLong at line 3, column 1", err)
+	}
+	if strings.Count(err.Error(), "line") != 1 {
+		t.Errorf("got error %q, the ParseError is wrapped twice", err)
+	}
+	r.WidthFor = func(prefix string) int { return 4 }
+	if _, err = r.ReadRecordAt(0); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("WidthFor: got error %v, want ErrInvalidLayout", err)
+	}
+}
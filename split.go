@@ -0,0 +1,69 @@
+package gofixedwidth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// splitChunk is the number of bytes read from the input at a time when SplitFunc needs more
+const splitChunk = 4096
+
+// readSplit - read the next record as decided by SplitFunc, the input that is read but not used yet
+// is kept in splitbuf. MaxLineLen (if defined) limits how much input is read for one record
+func (r *Reader) readSplit() (string, error) {
+	for {
+		if r.splitdone {
+			return "", io.EOF
+		}
+		if len(r.splitbuf) == 0 && !r.spliteof {
+			if err := r.fillSplit(); err != nil {
+				return "", err
+			}
+			continue
+		}
+		advance, token, err := r.SplitFunc(r.splitbuf, r.spliteof)
+		if advance < 0 || advance > len(r.splitbuf) {
+			return "", fmt.Errorf("SplitFunc advanced %d bytes but only %d are available", advance, len(r.splitbuf))
+		}
+		r.splitbuf = r.splitbuf[advance:]
+		if errors.Is(err, bufio.ErrFinalToken) {
+			r.splitdone = true
+			if token == nil {
+				return "", io.EOF
+			}
+			return string(token), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if token != nil {
+			return string(token), nil
+		}
+		if advance > 0 {
+			continue // Let SplitFunc look at the rest before reading more
+		}
+		if r.spliteof {
+			return "", io.EOF // Whatever is left isn't a record
+		}
+		if r.MaxLineLen > 0 && len(r.splitbuf) > r.MaxLineLen {
+			return "", &ParseError{Line: r.line, Column: r.MaxLineLen, Err: fmt.Errorf("%w: more than %d bytes", ErrLineTooLong, r.MaxLineLen)}
+		}
+		if err = r.fillSplit(); err != nil {
+			return "", err
+		}
+	}
+}
+
+// fillSplit - append the next chunk of input to splitbuf, spliteof is set at the end of the input
+func (r *Reader) fillSplit() error {
+	chunk := make([]byte, splitChunk)
+	n, err := r.r.Read(chunk)
+	r.splitbuf = append(r.splitbuf, chunk[:n]...)
+	if err == io.EOF {
+		r.spliteof = true
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package gofixedwidth
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitFunc(t *testing.T) {
+	r := newTestReader("aa;bb;cc;", 2)
+	r.SplitFunc = func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := strings.IndexByte(string(data), ';'); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	recs, err := readAll(t, r)
+	if want := [][]string{{"aa"}, {"bb"}, {"cc"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("got %q, %v, want %q", recs, err, want)
+	}
+}
+
+func TestSplitFuncWriteTo(t *testing.T) {
+	r := newTestReader("aa\nbb\ncc\n", 2)
+	r.SplitFunc = bufio.ScanLines
+	r.Init()
+	rec, err := r.Read()
+	if err != nil || !reflect.DeepEqual(rec, []string{"aa"}) {
+		t.Fatalf("Read: got %q, %v", rec, err)
+	}
+	var sb strings.Builder
+	n, err := r.WriteTo(&sb)
+	if err != nil || sb.String() != "bb\ncc\n" || n != int64(len("bb\ncc\n")) {
+		t.Errorf("WriteTo: got %q, %d, %v, want %q", sb.String(), n, err, "bb\ncc\n")
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read after WriteTo: got error %v, want io.EOF", err)
+	}
+}
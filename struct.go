@@ -0,0 +1,147 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidStruct = errors.New("invalid struct for fixed width record")
+
+// ReadStruct reads the next record with Read and fills the struct v points to from its fields, so all the
+// options of the Reader (FieldLengths, FieldAlign, trimming, FieldTransform and so on) apply to the values.
+// The exported fields of the struct with a fixed tag take the fields of the record in order:
+//   Name string `fixed:"len=10"`
+//   Codes []string `fixed:"occurs=3,len=4"`
+// A field with occurs must be a slice, it is filled from the next occurs fields of the record (a COBOL OCCURS,
+// see AddRepeatingGroup). len is optional, if given it must be the length of every record field that is used.
+// string, int, float64 and bool fields (and slices of them) are supported. The tagged fields together must not
+// need more fields than the record has, the record fields that are left over are ignored.
+func (r *Reader) ReadStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a pointer to a struct", ErrInvalidStruct, v)
+	}
+	flds, err := r.Read()
+	if err != nil {
+		return err
+	}
+	if col, err := r.unmarshalRecord(flds, rv.Elem()); err != nil {
+		return &ParseError{Line: r.line, Column: col, Err: err}
+	}
+	return nil
+}
+
+// unmarshalRecord - fill the struct rv from the fields of a record, the index of the record field is returned with an error
+func (r *Reader) unmarshalRecord(flds []string, rv reflect.Value) (int, error) {
+	rt := rv.Type()
+	col := 0
+	for i := 0; i < rt.NumField(); i++ {
+		tag, ok := rt.Field(i).Tag.Lookup("fixed")
+		if !ok || !rt.Field(i).IsExported() {
+			continue
+		}
+		length, occurs, err := parseFixedTag(tag)
+		if err != nil {
+			return col, fmt.Errorf("%w: field %s: %v", ErrInvalidStruct, rt.Field(i).Name, err)
+		}
+		fv := rv.Field(i)
+		if occurs > 0 && fv.Kind() != reflect.Slice {
+			return col, fmt.Errorf("%w: field %s has occurs but is not a slice", ErrInvalidStruct, rt.Field(i).Name)
+		}
+		n := max(occurs, 1)
+		if col+n > len(flds) {
+			return col, fmt.Errorf("%w: field %s needs %d fields from field %d but the record has %d", ErrFieldCount, rt.Field(i).Name, n, col, len(flds))
+		}
+		for k := col; k < col+n; k++ {
+			if length > 0 && k < len(r.FieldLengths) && r.FieldLengths[k] != length {
+				return k, fmt.Errorf("%w: field %s has len %d but the record field is %d long", ErrFieldLengthError, rt.Field(i).Name, length, r.FieldLengths[k])
+			}
+		}
+		if occurs == 0 {
+			if err = setValue(fv, flds[col]); err != nil {
+				return col, fmt.Errorf("field %s: %w", rt.Field(i).Name, err)
+			}
+			col++
+			continue
+		}
+		slice := reflect.MakeSlice(fv.Type(), occurs, occurs)
+		for k := 0; k < occurs; k++ {
+			if err = setValue(slice.Index(k), flds[col]); err != nil {
+				return col, fmt.Errorf("field %s[%d]: %w", rt.Field(i).Name, k, err)
+			}
+			col++
+		}
+		fv.Set(slice)
+	}
+	return col, nil
+}
+
+// parseFixedTag - get the length (0 if not given) and the number of occurrences (0 if not repeated) from a fixed tag
+func parseFixedTag(tag string) (length, occurs int, err error) {
+	for _, part := range strings.Split(tag, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		n, err := strconv.Atoi(val)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid %q in tag", part)
+		}
+		switch key {
+		case "len":
+			length = n
+		case "occurs":
+			occurs = n
+		default:
+			return 0, 0, fmt.Errorf("unknown %q in tag", key)
+		}
+	}
+	return length, occurs, nil
+}
+
+// fieldValue - the value of a field, trimmed if trim is set
+func fieldValue(fld string, trim bool) string {
+	if trim {
+		return strings.Trim(fld, " \t")
+	}
+	return fld
+}
+
+// setValue - convert fld to the type of v and set it, empty numbers and bools are set to their zero value
+func setValue(v reflect.Value, fld string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(fld)
+		return nil
+	}
+	tmp := strings.TrimSpace(fld)
+	if tmp == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(tmp, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(tmp, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(tmp)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("%w: unsupported type %s", ErrInvalidStruct, v.Type())
+	}
+	return nil
+}
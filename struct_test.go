@@ -0,0 +1,76 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type structRecord struct {
+	Name   string  `fixed:"len=6"`
+	Codes  []int   `fixed:"occurs=3,len=3"`
+	Amount float64 `fixed:""`
+	Active bool    `fixed:"len=1"`
+	Note   string
+}
+
+func TestReadStruct(t *testing.T) {
+	r := newTestReader("  bob 007 42100 12.51\n", 6, 5, 1)
+	r.TrimFields = true
+	r.FieldAlign = []int{ALIGNRIGHT, ALIGNRIGHT, ALIGNLEFT}
+	r.FieldTransform = []func(string) string{strings.ToUpper}
+	if err := r.AddRepeatingGroup(1, 3, []int{3}, []int{ALIGNRIGHT}); err != nil {
+		t.Fatalf("AddRepeatingGroup: %v", err)
+	}
+	var rec structRecord
+	if err := r.ReadStruct(&rec); err != nil {
+		t.Fatalf("ReadStruct: %v", err)
+	}
+	want := structRecord{Name: "BOB", Codes: []int{7, 42, 100}, Amount: 12.5, Active: true}
+	if !reflect.DeepEqual(rec, want) {
+		t.Errorf("got %+v, want %+v", rec, want)
+	}
+}
+
+func TestReadStructErrors(t *testing.T) {
+	var rec structRecord
+	r := newTestReader("abcdef\n", 6)
+	r.Init()
+	if err := r.ReadStruct(rec); !errors.Is(err, ErrInvalidStruct) {
+		t.Errorf("not a pointer: got error %v, want ErrInvalidStruct", err)
+	}
+	if err := r.ReadStruct(&rec); !errors.Is(err, ErrFieldCount) {
+		t.Errorf("too few fields: got error %v, want ErrFieldCount", err)
+	}
+
+	r = newTestReader("abcde1234\n", 5, 4)
+	r.Init()
+	var wrongLen struct {
+		A string `fixed:"len=5"`
+		B string `fixed:"len=3"`
+	}
+	err := r.ReadStruct(&wrongLen)
+	var perr *ParseError
+	if !errors.Is(err, ErrFieldLengthError) || !errors.As(err, &perr) || perr.Column != 1 {
+		t.Errorf("wrong len: got error %v, want ErrFieldLengthError in field 1", err)
+	}
+
+	r = newTestReader("abc\n", 3)
+	r.Init()
+	var notSlice struct {
+		A string `fixed:"occurs=1"`
+	}
+	if err := r.ReadStruct(&notSlice); !errors.Is(err, ErrInvalidStruct) {
+		t.Errorf("occurs without a slice: got error %v, want ErrInvalidStruct", err)
+	}
+
+	r = newTestReader("abc\n", 3)
+	r.Init()
+	var notNumber struct {
+		A int `fixed:"len=3"`
+	}
+	if err := r.ReadStruct(&notNumber); err == nil || !errors.As(err, &perr) || perr.Line != 1 {
+		t.Errorf("not a number: got error %v, want a ParseError on line 1", err)
+	}
+}
@@ -0,0 +1,77 @@
+package gofixedwidth
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// peekLine is a data line that has been read ahead to find out if it is the last one
+type peekLine struct {
+	text   string
+	err    error
+	line   int
+	eol    int
+	offset int64 // Offset of the input before the line (and the comment or empty lines in front of it)
+}
+
+// Trailer returns the fields of the trailer record (see TrailerMarker and TrailerLast)
+// or nil if no trailer has been read yet
+func (r *Reader) Trailer() []string {
+	return r.trailer
+}
+
+// readDataLine - read the next data line, a trailer line is parsed into the trailer and ends the records with io.EOF.
+// With TrailerLast one line is read ahead, the line numbers and Offset stay those of the line that is returned
+func (r *Reader) readDataLine() (string, error) {
+	if !r.TrailerLast {
+		tmp, err := r.nextDataLine()
+		if err != nil {
+			return "", err
+		}
+		if r.TrailerMarker != "" && strings.HasPrefix(tmp, r.TrailerMarker) {
+			return "", r.parseTrailer(tmp)
+		}
+		return tmp, nil
+	}
+	if r.peek == nil {
+		r.peek = r.peekDataLine()
+	}
+	cur := *r.peek
+	if cur.err != nil {
+		return "", cur.err
+	}
+	r.line, r.lasteol = cur.line, cur.eol // Read on from the line that is returned, the peek counts from there
+	r.peek = r.peekDataLine()
+	r.line, r.lasteol = cur.line, cur.eol
+	if r.peek.err == io.EOF || (r.TrailerMarker != "" && strings.HasPrefix(cur.text, r.TrailerMarker)) {
+		return "", r.parseTrailer(cur.text)
+	}
+	return cur.text, nil
+}
+
+// peekDataLine - read the next data line with the position after it
+func (r *Reader) peekDataLine() *peekLine {
+	offset := r.inputOffset()
+	tmp, err := r.nextDataLine()
+	return &peekLine{text: tmp, err: err, line: r.line, eol: r.lasteol, offset: offset}
+}
+
+// parseTrailer - split the trailer line with TrailerLengths and return io.EOF to end the records
+func (r *Reader) parseTrailer(line string) error {
+	if r.TrailerLengths == nil {
+		r.trailer = []string{line}
+		return io.EOF
+	}
+	trailer := make([]string, 0, len(r.TrailerLengths))
+	pos := 0
+	for _, length := range r.TrailerLengths {
+		if pos+length > len(line) {
+			return fmt.Errorf("%w: trailer is %d long but its fields need %d", ErrIncorrectLineWidth, len(line), pos+length)
+		}
+		trailer = append(trailer, fieldValue(line[pos:pos+length], r.TrimFields))
+		pos += length
+	}
+	r.trailer = trailer
+	return io.EOF
+}
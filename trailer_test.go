@@ -0,0 +1,98 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTrailerLast(t *testing.T) {
+	input := "abcd\n# note\nefgh\n\nijkl\nT002\n"
+	r := newTestReader(input, 2, 2)
+	r.Comment = '#'
+	r.TrailerLast = true
+	if err := r.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	tests := []struct {
+		want []string
+		n    int
+		line int
+	}{
+		{[]string{"ab", "cd"}, 5, 1},
+		{[]string{"ef", "gh"}, 12, 3}, // The comment in front of it counts as well
+		{[]string{"ij", "kl"}, 6, 5},
+	}
+	for _, tt := range tests {
+		rec, n, err := r.ReadN()
+		if err != nil || !reflect.DeepEqual(rec, tt.want) || n != tt.n {
+			t.Errorf("got %q, %d bytes, %v, want %q, %d bytes", rec, n, err, tt.want, tt.n)
+		}
+		if r.line != tt.line {
+			t.Errorf("got line %d, want %d", r.line, tt.line)
+		}
+	}
+	if off := r.Offset(); off != int64(strings.Index(input, "T002")) {
+		t.Errorf("got offset %d before the trailer, want %d", off, strings.Index(input, "T002"))
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("got error %v at the trailer, want io.EOF", err)
+	}
+	if want := []string{"T002"}; !reflect.DeepEqual(r.Trailer(), want) {
+		t.Errorf("got trailer %q, want %q", r.Trailer(), want)
+	}
+	if off := r.Offset(); off != int64(len(input)) {
+		t.Errorf("got offset %d at the end, want %d", off, len(input))
+	}
+}
+
+func TestTrailerLastRefused(t *testing.T) {
+	r := newTestReader("ab\ncd\nT\n", 2, 2)
+	r.TrailerLast = true
+	r.RecordLines = 2
+	if err := r.Init(); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("RecordLines: got error %v, want ErrInvalidLayout", err)
+	}
+
+	r = newTestReader("abcd\nefgh\nT\n", 2, 2)
+	r.TrailerLast = true
+	if _, err := readAll(t, r); err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	var sb strings.Builder
+	if _, err := r.WriteTo(&sb); err != nil {
+		t.Errorf("WriteTo after the trailer: %v", err)
+	}
+
+	r = newTestReader("abcd\nefgh\nT\n", 2, 2)
+	r.TrailerLast = true
+	r.Init()
+	if _, err := r.Read(); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if _, err := r.WriteTo(&sb); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("WriteTo with a line read ahead: got error %v, want ErrInvalidLayout", err)
+	}
+}
+
+func TestTrailerLastErrorLine(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"aa\nbb\ncc\nxxx\ndd\nT\n", 4},
+		{"aa\n# c\nbb\n\n# c\ncc\n\nxxx\ndd\nT\n", 8},
+	}
+	for _, tt := range tests {
+		r := newTestReader(tt.input, 1, 1)
+		r.Comment = '#'
+		r.TrailerLast = true
+		_, err := readAll(t, r)
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Line != tt.line || !errors.Is(err, ErrIncorrectLineWidth) {
+			t.Errorf("%q: got error %v, want an incorrect width on line %d", tt.input, err, tt.line)
+		}
+	}
+}
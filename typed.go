@@ -0,0 +1,132 @@
+package gofixedwidth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the Go type a field is converted to by ReadTyped
+type FieldType int
+
+const (
+	TYPESTRING FieldType = iota
+	TYPEINT
+	TYPEFLOAT
+	TYPEBOOL
+	TYPETIME
+)
+
+// ReadTyped reads the next record and converts every field to the type defined in FieldTypes:
+// string, int, float64, bool or time.Time (parsed with the FieldDateLayouts entry of the field or TimeLayout).
+// A field with an entry in FieldDateLayouts is always a time.Time. Fields without a type stay strings
+// and empty fields of the other types are returned as nil.
+// A field that can't be converted returns a ParseError with the index of the field as Column.
+func (r *Reader) ReadTyped() ([]interface{}, error) {
+	record, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(record))
+	for i, fld := range record {
+		typ := TYPESTRING
+		if i < len(r.FieldTypes) {
+			typ = r.FieldTypes[i]
+		}
+		if fieldLayout(r.FieldDateLayouts, i, "") != "" {
+			typ = TYPETIME // A field with a date layout is always a date
+		}
+		result[i], err = r.convertField(i, typ, fld)
+		if err != nil {
+			return nil, &ParseError{Line: r.line, Column: i, Err: err}
+		}
+	}
+	return result, nil
+}
+
+// convertField - convert the value of a field to typ
+func (r *Reader) convertField(i int, typ FieldType, fld string) (interface{}, error) {
+	if typ == TYPESTRING {
+		return fld, nil
+	}
+	tmp := strings.TrimSpace(fld)
+	if tmp == "" {
+		return nil, nil
+	}
+	switch typ {
+	case TYPEINT:
+		return strconv.Atoi(tmp)
+	case TYPEFLOAT:
+		return strconv.ParseFloat(tmp, 64)
+	case TYPEBOOL:
+		return strconv.ParseBool(tmp)
+	case TYPETIME:
+		tm, err := time.Parse(fieldLayout(r.FieldDateLayouts, i, r.timeLayout()), tmp)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %q as a date: %w", fld, err)
+		}
+		return tm, nil
+	}
+	return nil, fmt.Errorf("unknown field type %d", typ)
+}
+
+// timeLayout - the layout used to parse times, RFC3339 if TimeLayout isn't defined
+func (r *Reader) timeLayout() string {
+	if r.TimeLayout != "" {
+		return r.TimeLayout
+	}
+	return time.RFC3339
+}
+
+// fieldLayout - the date layout of field i or def if the field doesn't have one
+func fieldLayout(layouts []string, i int, def string) string {
+	if i < len(layouts) && layouts[i] != "" {
+		return layouts[i]
+	}
+	return def
+}
+
+// WriteTyped writes a record of values of any type. Strings are written as is, a time.Time is formatted with
+// the FieldDateLayouts entry of the field (or TimeLayout), nil is written as an empty field and
+// any other value is formatted with fmt.
+func (w *Writer) WriteTyped(vals []interface{}) error {
+	flds := make([]string, len(vals))
+	for i, val := range vals {
+		switch v := val.(type) {
+		case nil:
+			flds[i] = ""
+		case string:
+			flds[i] = v
+		case time.Time:
+			flds[i] = v.Format(fieldLayout(w.FieldDateLayouts, i, w.timeLayout()))
+		default:
+			flds[i] = fmt.Sprint(v)
+		}
+	}
+	return w.Write(flds)
+}
+
+// timeLayout - the layout used to format times, RFC3339 if TimeLayout isn't defined
+func (w *Writer) timeLayout() string {
+	if w.TimeLayout != "" {
+		return w.TimeLayout
+	}
+	return time.RFC3339
+}
+
+// parseType - convert the textual type of a layout field to one of the TYPE constants,
+// unknown types are treated as strings
+func parseType(typ string) FieldType {
+	switch strings.ToLower(typ) {
+	case "int", "integer":
+		return TYPEINT
+	case "float", "float64", "number":
+		return TYPEFLOAT
+	case "bool", "boolean":
+		return TYPEBOOL
+	case "time", "date":
+		return TYPETIME
+	}
+	return TYPESTRING
+}
@@ -0,0 +1,42 @@
+package gofixedwidth
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// segmentLength - the length of the variable segment of a record as given by its LengthField
+func (r *Reader) segmentLength(tmp string) (int, error) {
+	if r.LengthField < 0 || r.LengthField >= len(r.offsets) {
+		return 0, ErrFieldCount
+	}
+	rng := r.offsets[r.LengthField]
+	if rng[1] > len(tmp) {
+		return 0, ErrIncorrectLineWidth
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(tmp[rng[0]:rng[1]]))
+	if err != nil || n < 0 {
+		return 0, &ParseError{Line: r.line, Column: r.LengthField, Err: fmt.Errorf("%w: invalid segment length %q", ErrIncorrectLineWidth, tmp[rng[0]:rng[1]])}
+	}
+	return n, nil
+}
+
+// readLengthSegment - read the fixed part of a record and then the variable segment with the length given by LengthField
+func (r *Reader) readLengthSegment() (string, error) {
+	tmp, err := r.readWidth()
+	if err != nil || len(tmp) < r.width {
+		return tmp, err
+	}
+	n, err := r.segmentLength(tmp)
+	if err != nil {
+		return "", err
+	}
+	segment := make([]byte, n)
+	m, err := io.ReadFull(r.r, segment)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return tmp + string(segment[:m]), nil
+}
@@ -0,0 +1,69 @@
+package gofixedwidth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLengthField(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		eol   int
+		want  [][]string
+	}{
+		{"delimited", "A03xyz\nB00\nC05hello\n", EOLLF, [][]string{{"A", "03", "xyz"}, {"B", "00", ""}, {"C", "05", "hello"}}},
+		{"by width", "A03xyzB00C05hello", EOLNONE, [][]string{{"A", "03", "xyz"}, {"B", "00", ""}, {"C", "05", "hello"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader(tt.input, 1, 2)
+			r.HasEOL = tt.eol
+			r.HasLengthField = true
+			r.LengthField = 1
+			recs, err := readAll(t, r)
+			if err != nil || !reflect.DeepEqual(recs, tt.want) {
+				t.Errorf("got %q, %v, want %q", recs, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLengthFieldDisabledByDefault(t *testing.T) {
+	// The zero value of a Reader has no variable segment, even though LengthField is 0
+	flds, err := SplitFields("3abc", []int{1, 3}, false)
+	if want := []string{"3", "abc"}; err != nil || !reflect.DeepEqual(flds, want) {
+		t.Errorf("SplitFields: got %q, %v, want %q", flds, err, want)
+	}
+	r := NewReader(strings.NewReader("3abc\n"))
+	r.HasEOL = EOLLF
+	r.FieldLengths = []int{1, 3}
+	recs, err := readAll(t, r)
+	if want := [][]string{{"3", "abc"}}; err != nil || !reflect.DeepEqual(recs, want) {
+		t.Errorf("ReadAll: got %q, %v, want %q", recs, err, want)
+	}
+}
+
+func TestLengthFieldRefused(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *Reader)
+	}{
+		{"WidthFor", func(r *Reader) { r.WidthFor = func(prefix string) int { return 3 } }},
+		{"LastFieldRaw", func(r *Reader) { r.LastFieldRaw = true }},
+		{"FixedWidthEOL", func(r *Reader) { r.FixedWidthEOL = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestReader("A03xyz\n", 1, 2)
+			r.HasLengthField = true
+			r.LengthField = 1
+			tt.setup(r)
+			if err := r.Init(); !errors.Is(err, ErrInvalidLayout) {
+				t.Errorf("got error %v, want ErrInvalidLayout", err)
+			}
+		})
+	}
+}